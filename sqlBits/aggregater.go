@@ -1,5 +1,16 @@
 package sqlBits
 
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // Aggregate field names as keys mapped to values on SQL used to calc it.
 type Aggregate map[string]string
 
@@ -24,6 +35,110 @@ func (a RowCountAggregate) GetAggregateDefinition() Aggregate {
 	return a.def
 }
 
+// JsonArrayAggregate Returns an aggregate that builds a JSON array containing an
+// object per row of the given columns, keyed by column name, using the dialect
+// specific functions; e.g. PostgreSQL "json_agg(json_build_object(...))" and MySQL
+// "JSON_ARRAYAGG(JSON_OBJECT(...))". Use with CloneAsAggregate(). An error is
+// recorded (see GetErrors()) and an empty Aggregate returned for unsupported dialects.
+func (sqlbldr *Builder) JsonArrayAggregate( aColumns []string, aAlias string ) Aggregate {
+	var theObjFunc, theAggFunc string
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case MySQL:
+		theObjFunc, theAggFunc = "JSON_OBJECT", "JSON_ARRAYAGG"
+	case PostgreSQL:
+		theObjFunc, theAggFunc = "json_build_object", "json_agg"
+	case SQLite:
+		theObjFunc, theAggFunc = "json_object", "json_group_array"
+	default:
+		sqlbldr.addError(fmt.Errorf("sqlBits: JSON aggregation is not supported for driver %q", driverName))
+		return Aggregate{}
+	}//switch
+	theObjArgs := make([]string, len(aColumns))
+	for i, theColumn := range aColumns {
+		theObjArgs[i] = "'" + strings.Replace(theColumn, "'", "''", -1) + "', " + sqlbldr.GetQuoted(theColumn)
+	}
+	return Aggregate{
+		aAlias: theAggFunc + "(" + theObjFunc + "(" + strings.Join(theObjArgs, ", ") + "))",
+	}
+}
+
+// getColumnAggregate Returns an aggregate applying the SQL function to the quoted column.
+func (sqlbldr *Builder) getColumnAggregate( aFunc string, aColumnName string, aAlias string ) Aggregate {
+	return Aggregate{
+		aAlias: aFunc + "(" + sqlbldr.GetQuotedQualified(aColumnName) + ")",
+	}
+}
+
+// SumAggregate Returns an aggregate of the sum of the column, e.g. `sum("amount")`.
+// Use with CloneAsAggregate().
+func (sqlbldr *Builder) SumAggregate( aColumnName string, aAlias string ) Aggregate {
+	return sqlbldr.getColumnAggregate("sum", aColumnName, aAlias)
+}
+
+// AvgAggregate Returns an aggregate of the average of the column, e.g. `avg("amount")`.
+// Use with CloneAsAggregate().
+func (sqlbldr *Builder) AvgAggregate( aColumnName string, aAlias string ) Aggregate {
+	return sqlbldr.getColumnAggregate("avg", aColumnName, aAlias)
+}
+
+// MinAggregate Returns an aggregate of the minimum value of the column, e.g. `min("amount")`.
+// Use with CloneAsAggregate().
+func (sqlbldr *Builder) MinAggregate( aColumnName string, aAlias string ) Aggregate {
+	return sqlbldr.getColumnAggregate("min", aColumnName, aAlias)
+}
+
+// MaxAggregate Returns an aggregate of the maximum value of the column, e.g. `max("amount")`.
+// Use with CloneAsAggregate().
+func (sqlbldr *Builder) MaxAggregate( aColumnName string, aAlias string ) Aggregate {
+	return sqlbldr.getColumnAggregate("max", aColumnName, aAlias)
+}
+
+// CountDistinctAggregate Returns an aggregate of the number of distinct non-NULL
+// values of the column, e.g. `count(DISTINCT "user_id")`. Use with CloneAsAggregate().
+func (sqlbldr *Builder) CountDistinctAggregate( aColumnName string, aAlias string ) Aggregate {
+	return Aggregate{
+		aAlias: "count(DISTINCT " + sqlbldr.GetQuotedQualified(aColumnName) + ")",
+	}
+}
+
+// StringAggAggregate Returns an aggregate concatenating the non-NULL values of the
+// column into a single string joined by aSeparator, using the dialect specific
+// form; MySQL uses `GROUP_CONCAT("col" SEPARATOR ',')` while PostgreSQL and SQLite
+// use `string_agg("col", :separator)` and `group_concat("col", :separator)`
+// respectively. MySQL requires its separator be a string literal whereas the
+// others get it bound as a param of our Builder, so build the aggregate with the
+// Builder it will be used with. MySQL treats a backslash in a literal as an escape
+// char unless NO_BACKSLASH_ESCAPES is set, so such a separator records an error
+// (see GetErrors()) and an empty aggregate is returned. Use with CloneAsAggregate().
+func (sqlbldr *Builder) StringAggAggregate( aColumnName string, aSeparator string, aAlias string ) Aggregate {
+	theColumn := sqlbldr.GetQuotedQualified(aColumnName)
+	driverName := sqlbldr.dbMeta().Name
+	if driverName == MySQL {
+		if strings.Contains(aSeparator, `\`) {
+			sqlbldr.addError(fmt.Errorf("sqlBits: %s separator %q cannot contain a backslash", driverName, aSeparator))
+			return Aggregate{}
+		}
+		theSeparator := strings.Replace(aSeparator, "\x00", "", -1)
+		theSeparator = "'" + strings.Replace(theSeparator, "'", "''", -1) + "'"
+		return Aggregate{
+			aAlias: "GROUP_CONCAT(" + theColumn + " SEPARATOR " + theSeparator + ")",
+		}
+	}
+	theParamKey := sqlbldr.GetUniqueParamKey("separator")
+	sqlbldr.SetParam(theParamKey, aSeparator)
+	switch driverName {
+	case SQLite:
+		return Aggregate{
+			aAlias: "group_concat(" + theColumn + ", :" + theParamKey + ")",
+		}
+	default:
+		return Aggregate{
+			aAlias: "string_agg(" + theColumn + ", :" + theParamKey + ")",
+		}
+	}//switch
+}
+
 // CloneAsAggregate Sometimes we want to aggregate the query somehow rather than return data from it.
 func (sqlbldr *Builder) CloneAsAggregate( aSqlAggragates Aggregater ) *Builder {
 	if aSqlAggragates == nil {
@@ -39,3 +154,192 @@ func (sqlbldr *Builder) CloneAsAggregate( aSqlAggragates Aggregater ) *Builder {
 	theNewBuilder := *sqlbldr
 	return theNewBuilder.ReplaceSelectFieldsWith(&theFieldList)
 }
+
+// CloneAsAggregates Same as CloneAsAggregate() except that the definitions of all
+// the given aggregates are combined into a single query. Should an alias be used
+// by more than one definition, later ones are renamed with a numeric suffix,
+// e.g. "total_2", unless they define the very same SQL.
+func (sqlbldr *Builder) CloneAsAggregates( aSqlAggragates ...Aggregater ) *Builder {
+	theAliases := map[string]string{}
+	theFieldList := []string{}
+	for _, theAggregater := range aSqlAggragates {
+		if theAggregater == nil {
+			continue
+		}
+		theAggregateDef := theAggregater.GetAggregateDefinition()
+		theKeys := make([]string, 0, len(theAggregateDef))
+		for k := range theAggregateDef {
+			theKeys = append(theKeys, k)
+		}
+		sort.Strings(theKeys)
+		for _, k := range theKeys {
+			v := theAggregateDef[k]
+			theAlias := k
+			for i := 2; ; i += 1 {
+				if theExistingDef, found := theAliases[theAlias]; !found || theExistingDef == v {
+					break
+				}
+				theAlias = k + "_" + strconv.Itoa(i)
+			}
+			if _, found := theAliases[theAlias]; found {
+				continue
+			}
+			theAliases[theAlias] = v
+			theFieldList = append(theFieldList, v+" AS "+theAlias)
+		}
+	}
+	if len(theFieldList) == 0 {
+		return sqlbldr.CloneAsAggregate(&TotalRowCount)
+	}
+	theNewBuilder := *sqlbldr
+	return theNewBuilder.ReplaceSelectFieldsWith(&theFieldList)
+}
+
+// AsDistinctCountOf Returns a new Builder whose query counts the distinct tuples
+// of the given columns for our current query, reusing its WHERE clause and params
+// while stripping any ORDER BY/LIMIT, e.g.
+// "SELECT count(*) AS rowcount FROM (SELECT DISTINCT "a", "b" FROM t WHERE ...) AS sub"
+func (sqlbldr *Builder) AsDistinctCountOf( aColumns []string ) *Builder {
+	theFieldList := make([]string, len(aColumns))
+	for i, theColumn := range aColumns {
+		theFieldList[i] = sqlbldr.GetQuoted(theColumn)
+	}
+	if len(theFieldList) > 0 {
+		theFieldList[0] = "DISTINCT " + theFieldList[0]
+	}
+	theNewBuilder := *sqlbldr
+	theNewBuilder.mySql = sqlbldr.getSqlWithoutOrderByOrLimit()
+	theNewBuilder.ReplaceSelectFieldsWith(&theFieldList)
+	theNewBuilder.mySql = "SELECT count(*) AS rowcount FROM (" + theNewBuilder.mySql + ") AS sub"
+	return &theNewBuilder
+}
+
+// BuildCount Returns a new Builder whose query counts the rows our current query
+// would return, ignoring any ORDER BY/LIMIT. Queries using GROUP BY, DISTINCT, or
+// UNION are wrapped, "SELECT count(*) AS rowcount FROM (...) AS t", so that groups
+// rather than the underlying rows are counted; others simply have their field list
+// replaced as with CloneAsAggregate(&TotalRowCount).
+func (sqlbldr *Builder) BuildCount() *Builder {
+	theNewBuilder := *sqlbldr
+	theNewBuilder.mySql = sqlbldr.getSqlWithoutOrderByOrLimit()
+	if sqlbldr.isGroupedQuery() {
+		theNewBuilder.mySql = "SELECT count(*) AS rowcount FROM (" + theNewBuilder.mySql + ") AS t"
+		return &theNewBuilder
+	}
+	return theNewBuilder.CloneAsAggregate(&TotalRowCount)
+}
+
+// BuildPagerTotalQuery Returns a new Builder whose query counts the total rows our
+// current query would return regardless of paging, see BuildCount(), but only if
+// the pager desires the total row count; otherwise nil is returned.
+func (sqlbldr *Builder) BuildPagerTotalQuery( aPager IPagedResults ) *Builder {
+	if aPager == nil || !aPager.IsTotalRowCountDesired() {
+		return nil
+	}
+	return sqlbldr.BuildCount()
+}
+
+// isGroupedQuery Returns TRUE if our query uses GROUP BY, SELECT DISTINCT, or UNION
+// such that counting its rows requires counting the result of the whole query.
+func (sqlbldr *Builder) isGroupedQuery() bool {
+	return indexOfTopLevelKeyword(sqlbldr.mySql, reKeywordGroupBy) >= 0 ||
+		indexOfTopLevelKeyword(sqlbldr.mySql, reKeywordSelectDistinct) >= 0 ||
+		indexOfTopLevelKeyword(sqlbldr.mySql, reKeywordUnion) >= 0
+}
+
+// ExecuteAggregateInto Run the query as an aggregate defined by aDest and scan the
+// single resulting row into aDest. Aggregate definition keys are matched to the
+// exported fields of aDest (by query field name or case-insensitive field name),
+// e.g. the "rowcount" key of TotalRowCount scans into RowCountAggregate.Rowcount.
+// aDest must be a pointer to a struct. TotalRowCount is shared, so scan into a
+// local copy of it rather than into &TotalRowCount:
+//   theCount := TotalRowCount
+//   err := sqlbldr.ExecuteAggregateInto(aDb, &theCount)
+func (sqlbldr *Builder) ExecuteAggregateInto( aDb SqlExecuter, aDest Aggregater ) error {
+	theDestVal := reflect.ValueOf(aDest)
+	if aDest == nil || theDestVal.Kind() != reflect.Ptr || theDestVal.Elem().Kind() != reflect.Struct {
+		return errors.New("sqlBits: aggregate destination must be a pointer to a struct")
+	}
+	theDestVal = theDestVal.Elem()
+	theRows, err := sqlbldr.CloneAsAggregate(aDest).Query(context.Background(), aDb)
+	if err != nil {
+		return err
+	}
+	defer theRows.Close()
+	theColumns, err := theRows.Columns()
+	if err != nil {
+		return err
+	}
+	if !theRows.Next() {
+		if err = theRows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	theScanDest := make([]interface{}, len(theColumns))
+	for i, theColName := range theColumns {
+		if theField := getAggregateFieldForKey(theDestVal, theColName); theField.IsValid() {
+			theScanDest[i] = theField.Addr().Interface()
+		} else {
+			// unmapped aggregate, scan it and toss it
+			theScanDest[i] = new(interface{})
+		}
+	}
+	if err = theRows.Scan(theScanDest...); err != nil {
+		return err
+	}
+	return theRows.Close()
+}
+
+// PopulateTotalRowCount Run the count query for our current query, see BuildCount(),
+// and pass the resulting total to aResults.SetTotalRowCount(). Should aResults
+// also be an IPagedResults not desiring the total row count, nothing is done.
+func (sqlbldr *Builder) PopulateTotalRowCount( ctx context.Context, aDb SqlExecuter,
+	aResults ResultsWithRowCounter,
+) error {
+	if aResults == nil {
+		return errors.New("sqlBits: no results to set the total row count of")
+	}
+	var theCountQuery *Builder
+	if thePager, ok := aResults.(IPagedResults); ok {
+		if theCountQuery = sqlbldr.BuildPagerTotalQuery(thePager); theCountQuery == nil {
+			return nil
+		}
+	} else {
+		theCountQuery = sqlbldr.BuildCount()
+	}
+	theRows, err := theCountQuery.Query(ctx, aDb)
+	if err != nil {
+		return err
+	}
+	defer theRows.Close()
+	if !theRows.Next() {
+		if err = theRows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	var theTotal int64
+	if err = theRows.Scan(&theTotal); err != nil {
+		return err
+	}
+	if err = theRows.Close(); err != nil {
+		return err
+	}
+	aResults.SetTotalRowCount(theTotal)
+	return nil
+}
+
+// getAggregateFieldForKey Returns the settable struct field that maps to an
+// aggregate key, or the zero Value if none match.
+func getAggregateFieldForKey( aStructVal reflect.Value, aKey string ) reflect.Value {
+	theStructType := aStructVal.Type()
+	for i := 0; i < theStructType.NumField(); i++ {
+		theField := theStructType.Field(i)
+		if IsStructFieldExported(theField) && (GetQueryFieldNameOfStructField(theField) == aKey ||
+			strings.EqualFold(theField.Name, aKey)) {
+			return aStructVal.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
@@ -0,0 +1,177 @@
+package sqlBits
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// aggregateTest An aggregate built by a dialect's builder, see runAggregateTests().
+type aggregateTest struct {
+	name    string
+	driver  DriverName
+	agg     func( b *Builder ) Aggregate
+	want    Aggregate
+	wantErr string
+}
+
+// runAggregateTests Checks each built aggregate as well as any error recorded,
+// which must contain wantErr if set.
+func runAggregateTests( t *testing.T, aTests []aggregateTest ) {
+	t.Helper()
+	for _, tt := range aTests {
+		t.Run(tt.name, func( t *testing.T ) {
+			b := newTestBuilder(tt.driver)
+			if got := tt.agg(b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			theErrs := strings.Join(errorsOf(b), "; ")
+			if (theErrs != "") != (tt.wantErr != "") || !strings.Contains(theErrs, tt.wantErr) {
+				t.Errorf("got errors %q, want %q", theErrs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJsonArrayAggregate( t *testing.T ) {
+	runAggregateTests(t, []aggregateTest{
+		{"postgres", PostgreSQL, func( b *Builder ) Aggregate { return b.JsonArrayAggregate([]string{"id", "it's"}, "j") },
+			Aggregate{"j": `json_agg(json_build_object('id', "id", 'it''s', "it's"))`}, ""},
+		{"mysql", MySQL, func( b *Builder ) Aggregate { return b.JsonArrayAggregate([]string{"id"}, "j") },
+			Aggregate{"j": "JSON_ARRAYAGG(JSON_OBJECT('id', `id`))"}, ""},
+		{"sqlite", SQLite, func( b *Builder ) Aggregate { return b.JsonArrayAggregate([]string{"id"}, "j") },
+			Aggregate{"j": `json_group_array(json_object('id', "id"))`}, ""},
+		{"sql server", MSSQL, func( b *Builder ) Aggregate { return b.JsonArrayAggregate([]string{"id"}, "j") },
+			Aggregate{}, "not supported"},
+	})
+}
+
+func TestColumnAggregates( t *testing.T ) {
+	runAggregateTests(t, []aggregateTest{
+		{"sum", PostgreSQL, func( b *Builder ) Aggregate { return b.SumAggregate("t.amount", "total") },
+			Aggregate{"total": `sum("t"."amount")`}, ""},
+		{"avg", MySQL, func( b *Builder ) Aggregate { return b.AvgAggregate("amount", "a") },
+			Aggregate{"a": "avg(`amount`)"}, ""},
+		{"min", SQLite, func( b *Builder ) Aggregate { return b.MinAggregate("amount", "lo") },
+			Aggregate{"lo": `min("amount")`}, ""},
+		{"max", PostgreSQL, func( b *Builder ) Aggregate { return b.MaxAggregate("amount", "hi") },
+			Aggregate{"hi": `max("amount")`}, ""},
+	})
+}
+
+func TestCountDistinctAggregate( t *testing.T ) {
+	runAggregateTests(t, []aggregateTest{
+		{"count distinct", PostgreSQL, func( b *Builder ) Aggregate { return b.CountDistinctAggregate("user_id", "users") },
+			Aggregate{"users": `count(DISTINCT "user_id")`}, ""},
+	})
+}
+
+func TestStringAggAggregate( t *testing.T ) {
+	runAggregateTests(t, []aggregateTest{
+		{"postgres", PostgreSQL, func( b *Builder ) Aggregate { return b.StringAggAggregate("n", "', '", "s") },
+			Aggregate{"s": `string_agg("n", :separator)`}, ""},
+		{"mysql", MySQL, func( b *Builder ) Aggregate { return b.StringAggAggregate("n", "','", "s") },
+			Aggregate{"s": "GROUP_CONCAT(`n` SEPARATOR ''',''')"}, ""},
+		{"mysql backslash", MySQL, func( b *Builder ) Aggregate { return b.StringAggAggregate("n", `\n`, "s") },
+			Aggregate{}, "backslash"},
+		{"sqlite", SQLite, func( b *Builder ) Aggregate { return b.StringAggAggregate("n", "|", "s") },
+			Aggregate{"s": `group_concat("n", :separator)`}, ""},
+	})
+	t.Run("binds the separator", func( t *testing.T ) {
+		b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").SetParam("separator", "x")
+		theAgg := b.StringAggAggregate("n", "', '", "s")
+		if got := b.GetParam("separator2"); got == nil || *got != "', '" {
+			t.Errorf("got separator param %v", got)
+		}
+		theQuery := b.CloneAsAggregate(theAgg)
+		if got, want := theQuery.SQL(), `SELECT string_agg("n", $1) AS s FROM t`; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if got := theQuery.SQLargs(); !reflect.DeepEqual(got, []interface{}{"', '"}) {
+			t.Errorf("got args %v", got)
+		}
+	})
+}
+
+func TestCloneAsAggregates( t *testing.T ) {
+	newQuery := func() *Builder {
+		return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+			SetParam("a", "1").MustAddParam("a")
+	}
+	runSqlTests(t, []sqlTest{
+		{"row count", func() *Builder {
+			return newQuery().CloneAsAggregate(nil)
+		}, `SELECT count(*) AS rowcount FROM t WHERE "a"=:a`, ""},
+		{"combined", func() *Builder {
+			b := newQuery()
+			return b.CloneAsAggregates(b.SumAggregate("x", "total"), nil, &TotalRowCount)
+		}, `SELECT sum("x") AS total, count(*) AS rowcount FROM t WHERE "a"=:a`, ""},
+		{"colliding aliases", func() *Builder {
+			b := newQuery()
+			return b.CloneAsAggregates(b.SumAggregate("x", "total"), b.SumAggregate("y", "total"),
+				b.SumAggregate("x", "total"))
+		}, `SELECT sum("x") AS total, sum("y") AS total_2 FROM t WHERE "a"=:a`, ""},
+		{"none", func() *Builder {
+			return newQuery().CloneAsAggregates()
+		}, `SELECT count(*) AS rowcount FROM t WHERE "a"=:a`, ""},
+	})
+	b := newQuery()
+	b.CloneAsAggregate(nil)
+	if got := b.GetSQLStatement(); got != `SELECT * FROM t WHERE "a"=:a` {
+		t.Errorf("the original query changed to %q", got)
+	}
+}
+
+func TestAsDistinctCountOf( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"distinct tuples", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").MustAddParam("a").Add("ORDER BY b").AsDistinctCountOf([]string{"b", "c"})
+		}, `SELECT count(*) AS rowcount FROM (SELECT DISTINCT "b", "c" FROM t WHERE "a"=:a) AS sub`, ""},
+	})
+}
+
+func TestBuildCount( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"plain", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT a, b FROM t").Add("ORDER BY a").AddQueryLimit(10, 0).BuildCount()
+		}, `SELECT count(*) AS rowcount FROM t`, ""},
+		{"group by", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT a, count(*) FROM t GROUP BY a ORDER BY a").BuildCount()
+		}, `SELECT count(*) AS rowcount FROM (SELECT a, count(*) FROM t GROUP BY a) AS t`, ""},
+		{"distinct", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT DISTINCT a FROM t").BuildCount()
+		}, `SELECT count(*) AS rowcount FROM (SELECT DISTINCT a FROM t) AS t`, ""},
+		{"union", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT a FROM t UNION SELECT a FROM u").BuildCount()
+		}, `SELECT count(*) AS rowcount FROM (SELECT a FROM t UNION SELECT a FROM u) AS t`, ""},
+		{"group by in a sub-query", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT a FROM t WHERE a IN (SELECT a FROM u GROUP BY a)").BuildCount()
+		}, `SELECT count(*) AS rowcount FROM t WHERE a IN (SELECT a FROM u GROUP BY a)`, ""},
+	})
+}
+
+// testPager An IPagedResults and ResultsWithRowCounter for tests.
+type testPager struct {
+	wantTotal bool
+	total     int64
+}
+
+func (p *testPager) IsTotalRowCountDesired() bool { return p.wantTotal }
+func (p *testPager) GetPagerPageSize() int64 { return 10 }
+func (p *testPager) GetPagerQueryOffset() int64 { return 0 }
+func (p *testPager) SetTotalRowCount( aTotalRowCount int64 ) { p.total = aTotalRowCount }
+
+func TestBuildPagerTotalQuery( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT a FROM t")
+	if got := b.BuildPagerTotalQuery(&testPager{wantTotal: false}); got != nil {
+		t.Errorf("got %q for a pager not desiring a total", got.GetSQLStatement())
+	}
+	if got := b.BuildPagerTotalQuery(nil); got != nil {
+		t.Errorf("got %q for a nil pager", got.GetSQLStatement())
+	}
+	theQuery := b.BuildPagerTotalQuery(&testPager{wantTotal: true})
+	if theQuery == nil || theQuery.GetSQLStatement() != `SELECT count(*) AS rowcount FROM t` {
+		t.Errorf("got %v", theQuery)
+	}
+}
@@ -1,9 +1,15 @@
 package sqlBits
 
 import (
+	"database/sql"
+	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type DbModeler interface {
@@ -18,9 +24,52 @@ type IDataSource interface {
 	GetValueListForKey( aKey string ) *[]string
 }
 
-// OrderByList Keys are field names, values are either ORDER_BY_* consts: 'ASC' or 'DESC'.
+// OrderByList Keys are field names, values are either ORDER_BY_* consts: 'ASC' or 'DESC',
+// optionally followed by ORDER_BY_NULLS_FIRST or ORDER_BY_NULLS_LAST, e.g. "DESC NULLS LAST".
 type OrderByList map[string]string
 
+// OrderBy A field to sort by along with its direction, which takes the same form
+// as an OrderByList value, e.g. "DESC NULLS LAST".
+type OrderBy struct {
+	Field     string
+	Direction string
+}
+
+// OrderBySequence Fields to sort by in order of precedence. Unlike OrderByList,
+// which is a map and so has no order, the order of its entries is kept.
+type OrderBySequence []OrderBy
+
+// AsSequence Returns the list as an OrderBySequence; since a map has no order,
+// the fields are sorted by name so that the result is at least deterministic.
+func (aList OrderByList) AsSequence() OrderBySequence {
+	theFields := make([]string, 0, len(aList))
+	for k := range aList {
+		theFields = append(theFields, k)
+	}
+	sort.Strings(theFields)
+	theResult := make(OrderBySequence, len(theFields))
+	for i, theField := range theFields {
+		theResult[i] = OrderBy{Field: theField, Direction: aList[theField]}
+	}
+	return theResult
+}
+
+// AsList Returns the sequence as an OrderByList, losing its order.
+func (aSequence OrderBySequence) AsList() OrderByList {
+	theResult := OrderByList{}
+	for _, theEntry := range aSequence {
+		theResult[theEntry.Field] = theEntry.Direction
+	}
+	return theResult
+}
+
+// SqlLogger Callback used to observe the SQL statements a Builder produces.
+type SqlLogger func( aSql string, aArgs []interface{} )
+
+// SqlExecLogger Callback used to observe the SQL statements a Builder runs along
+// with how long they took and any error returned.
+type SqlExecLogger func( aSql string, aArgs []interface{}, aElapsed time.Duration, aErr error )
+
 // Builder Use this class to help build SQL queries.
 // Supports: MySQL and Postgres.
 type Builder struct {
@@ -34,29 +83,35 @@ type Builder struct {
 	// If set, parameter data is retrieved from it.
 	myDataSource IDataSource
 
-	/**
-	 * The object used to sanitize field/orderby lists to help prevent
-	 * SQL injection attacks.
-	 * @var ISqlSanitizer
-	 */
-	//public $mySqlSanitizer = null;
+	// If set, used to sanitize field/orderby lists to help prevent
+	// SQL injection attacks.
+	mySqlSanitizer ISqlSanitizer
 
 	// The SQL string being built.
 	mySql           string
 	// SQL statement parameters to use (contains all keys from mySetParams, too).
 	myParams        map[string]*string
-	// used by SQL() if driver does not support named parameters
-	myOrdQuerySql   string
-	// used by SQL() if driver does not support named parameters
-	myOrdQueryArgs  []interface{}
 	// SQL statement set parameters to use.
 	mySetParams     map[string]*[]string
+	// SQL statement parameters bound with their native type; their keys are in
+	// myParams as well, holding the string form of the value.
+	myTypedParams   map[string]interface{}
 	// SQL statement parameter types (not sure if we need them, yet)
 	//myParamTypes    map[string]string
 	// Prefix for a parameter about to be added.
 	myParamPrefix   string
 	// Operator for the parameter to use. e.g. " LIKE ", "=", "<>", etc.
 	myParamOperator string
+	// Prefix given to our param keys once merged into another builder; see
+	// WithParamNamespace().
+	myParamNamespace string
+	// Number of builders handed out by NewSubBuilder(); unaffected by Reset().
+	mySubBuilderCount int
+	// ParamPrefix in effect before each currently open parentheses group was
+	// opened, innermost last; see OpenParen().
+	myParenPrefixes []string
+	// Saved param prefix/operator/NULL handling states; see PushParamState().
+	myParamStates   []paramState
 
 	// Using the "=" when NULL is involved is ambiguous unless you know
 	// if it is part of a SET clause or WHERE clause.  Explicitly set
@@ -64,6 +119,34 @@ type Builder struct {
 	bUseIsNull bool
 	// Same as bUseIsNull, but for SET clauses.
 	bUseSetNull bool
+	// If set, AddFieldList() quotes each field name; unaffected by Reset().
+	bQuoteFieldList bool
+	// If set, SQL() calls Validate() and records any error; unaffected by Reset().
+	bValidateOnSQL bool
+	// Maximum values in a single IN list, 0 for no limit; unaffected by Reset().
+	myMaxInListSize int
+	// Whitelisted SQL expressions to sort by, keyed by name; unaffected by Reset().
+	myOrderByExprs map[string]string
+	// If set, called with each statement SQL() produces; unaffected by Reset().
+	myLogger SqlLogger
+	// If set, called after each Query()/Exec() with its duration; unaffected by Reset().
+	myExecLogger SqlExecLogger
+
+	// Position in mySql where the WHERE clause was started, -1 if not started.
+	myWhereStart int
+	// The WHERE clause SQL captured once the WHERE clause has ended.
+	myWhereSql *string
+	// Set by StartFilter() as all of our SQL is then a partial WHERE clause.
+	bIsFilter bool
+	// Param keys defined prior to starting the WHERE clause.
+	myPreWhereParams map[string]bool
+	// Param keys added while building the WHERE clause.
+	myWhereParamKeys []string
+	// Columns of the DISTINCT ON clause which must lead the ORDER BY; see AddDistinctOn().
+	myDistinctOn []string
+
+	// Errors encountered while building the SQL; see GetErrors().
+	myErrors []error
 }
 
 // NewBuilder Models can use this package to help build their SQL queries.
@@ -80,19 +163,92 @@ func (sqlbldr *Builder) WithModel( aDbModeler DbModeler ) *Builder {
 	return sqlbldr.Reset()
 }
 
+// NewSubBuilder Returns a new builder using our model meant for building a
+// sub-query inline, e.g. to pass to AddSubQueryForColumn(). Its params get a
+// namespace unique to us, "sq1_", "sq2_", etc. (see WithParamNamespace()), so
+// they never collide with ours nor those of other sub-builders once merged in,
+// even when sub-builders are nested within one another.
+func (sqlbldr *Builder) NewSubBuilder() *Builder {
+	sqlbldr.mySubBuilderCount += 1
+	return NewBuilder(sqlbldr.myDbModel).WithParamNamespace("sq" +
+		strconv.Itoa(sqlbldr.mySubBuilderCount) + "_")
+}
+
+// dbMeta Returns the driver info of our model. Should there be no model or its
+// driver is unknown, a default is returned so that we degrade to standard SQL
+// rather than panic, e.g. identifiers are quoted using the ANSI '"' and named
+// params use the ':' sigil.
+func (sqlbldr *Builder) dbMeta() *DriverInfo {
+	var theMeta *DriverInfo
+	if sqlbldr.myDbModel != nil {
+		theMeta = sqlbldr.myDbModel.GetDbMeta()
+	}
+	if theMeta == nil {
+		return &DriverInfo{IdentifierDelimiter: '"', ParamSigil: ':'}
+	}
+	if theMeta.IdentifierDelimiter == 0 || theMeta.ParamSigil == 0 {
+		theDefaultMeta := *theMeta
+		if theDefaultMeta.IdentifierDelimiter == 0 {
+			theDefaultMeta.IdentifierDelimiter = '"'
+		}
+		if theDefaultMeta.ParamSigil == 0 {
+			theDefaultMeta.ParamSigil = ':'
+		}
+		return &theDefaultMeta
+	}
+	return theMeta
+}
+
 // Reset Resets the object so it can be resused without creating a new instance.
 func (sqlbldr *Builder) Reset() *Builder {
 	sqlbldr.mySql = ""
+	sqlbldr.myDistinctOn = nil
 	sqlbldr.myParams = map[string]*string{}
 	sqlbldr.mySetParams = map[string]*[]string{}
+	sqlbldr.myTypedParams = map[string]interface{}{}
 	//sqlbldr.myParamTypes = map[string]string{}
 	sqlbldr.myParamPrefix = " "
 	sqlbldr.myParamOperator = "="
+	sqlbldr.myParenPrefixes = nil
+	sqlbldr.myParamStates = nil
 	sqlbldr.bUseIsNull = false
 	sqlbldr.bUseSetNull = false
+	sqlbldr.clearWhereTracking()
+	sqlbldr.myErrors = nil
+	return sqlbldr
+}
+
+// ResetSQL Clears the SQL string being built while keeping all the params that
+// have been set so that a statement may be rebuilt using the same bound values.
+func (sqlbldr *Builder) ResetSQL() *Builder {
+	sqlbldr.mySql = ""
+	sqlbldr.myDistinctOn = nil
+	sqlbldr.myParenPrefixes = nil
+	sqlbldr.clearWhereTracking()
+	return sqlbldr
+}
+
+// addError Record an error encountered while building the SQL.
+func (sqlbldr *Builder) addError( aErr error ) *Builder {
+	sqlbldr.myErrors = append(sqlbldr.myErrors, aErr)
 	return sqlbldr
 }
 
+// GetErrors Returns the errors encountered while building the SQL, if any.
+// Methods that cannot produce valid SQL for the current dialect record an error
+// rather than emit broken SQL.
+func (sqlbldr *Builder) GetErrors() []error {
+	return sqlbldr.myErrors
+}
+
+// Err Returns the first error encountered while building the SQL, or nil.
+func (sqlbldr *Builder) Err() error {
+	if len(sqlbldr.myErrors) > 0 {
+		return sqlbldr.myErrors[0]
+	}
+	return nil
+}
+
 // BeginTransaction If we are not already in a transaction, start one.
 func (sqlbldr *Builder) BeginTransaction() *Builder {
 	if sqlbldr.myTransactionFlag < 1 {
@@ -126,11 +282,76 @@ func (sqlbldr *Builder) RollbackTransaction() *Builder {
 
 // GetQuoted Quoted identifiers are DB vendor specific so providing a helper method
 // to just return a properly quoted string for MySQL vs MSSQL vs Oracle, etc. is handy.
+// The result is always a single identifier: any delimiter within is escaped by
+// doubling it and NUL bytes, which no database allows even when quoted and which
+// may end the statement early for C based parsers, are stripped. Other chars,
+// such as newlines, are valid inside quotes and kept; see ValidateIdentifier().
 func (sqlbldr *Builder) GetQuoted( aIdentifier string ) string {
-	delim := string(sqlbldr.myDbModel.GetDbMeta().IdentifierDelimiter)
+	delim := string(sqlbldr.dbMeta().IdentifierDelimiter)
+	aIdentifier = strings.Replace(aIdentifier, "\x00", "", -1)
 	return delim + strings.Replace(aIdentifier, delim, delim+delim, -1) + delim
 }
 
+// Unquote The inverse of GetQuoted(); strips the surrounding identifier
+// delimiters and un-doubles any embedded ones. An identifier that is not
+// quoted is returned as-is.
+func (sqlbldr *Builder) Unquote( aIdentifier string ) string {
+	delim := string(sqlbldr.dbMeta().IdentifierDelimiter)
+	if len(aIdentifier) >= 2*len(delim) && strings.HasPrefix(aIdentifier, delim) &&
+		strings.HasSuffix(aIdentifier, delim) {
+		theInner := aIdentifier[len(delim):len(aIdentifier)-len(delim)]
+		return strings.Replace(theInner, delim+delim, delim, -1)
+	}
+	return aIdentifier
+}
+
+// GetQuotedQualified Quotes each part of a possibly schema-qualified name such as
+// "public.users" so that it becomes "public"."users" rather than "public.users".
+func (sqlbldr *Builder) GetQuotedQualified( aName string ) string {
+	theParts := strings.Split(aName, ".")
+	for i, thePart := range theParts {
+		theParts[i] = sqlbldr.GetQuoted(thePart)
+	}
+	return strings.Join(theParts, ".")
+}
+
+// ValidateIdentifier Returns an error if the identifier (a single table or column
+// name, not a "table.field" one) cannot be used as-is by our database, even when
+// quoted; e.g. names longer than PostgreSQL's 63 byte or MySQL's 64 char limit
+// would be rejected or, worse, silently truncated. NUL chars are never allowed.
+func (sqlbldr *Builder) ValidateIdentifier( aName string ) error {
+	if aName == "" {
+		return fmt.Errorf("sqlBits: identifier is empty")
+	}
+	if !utf8.ValidString(aName) || strings.ContainsRune(aName, 0) {
+		return fmt.Errorf("sqlBits: identifier %q contains invalid characters", aName)
+	}
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case PostgreSQL:
+		if len(aName) > 63 {
+			return fmt.Errorf("sqlBits: identifier %q exceeds %s's limit of 63 bytes", aName, driverName)
+		}
+	case MySQL:
+		if utf8.RuneCountInString(aName) > 64 {
+			return fmt.Errorf("sqlBits: identifier %q exceeds %s's limit of 64 characters", aName, driverName)
+		}
+		if strings.HasSuffix(aName, " ") {
+			return fmt.Errorf("sqlBits: identifier %q may not end with a space for %s", aName, driverName)
+		}
+		for _, r := range aName {
+			if r > 0xFFFF {
+				return fmt.Errorf("sqlBits: identifier %q contains characters %s cannot represent", aName, driverName)
+			}
+		}
+	case MSSQL:
+		if utf8.RuneCountInString(aName) > 128 {
+			return fmt.Errorf("sqlBits: identifier %q exceeds %s's limit of 128 characters", aName, driverName)
+		}
+	}//switch
+	return nil
+}
+
 // StartWith Sets the SQL string to this value to build upon.
 func (sqlbldr *Builder) StartWith( aSql string ) *Builder {
 	sqlbldr.mySql = aSql
@@ -144,16 +365,27 @@ func (sqlbldr *Builder) StartWith( aSql string ) *Builder {
 // using ApplyFilter().
 func (sqlbldr *Builder) StartFilter() *Builder {
 	sqlbldr.bUseIsNull = true
-	driverName := sqlbldr.myDbModel.GetDbMeta().Name
+	sqlbldr.StartWith(sqlbldr.getBoolLiteral(true))
+	sqlbldr.clearWhereTracking()
+	sqlbldr.bIsFilter = true
+	return sqlbldr.SetParamPrefix(" AND ")
+}
+
+// getBoolLiteral Returns the dialect specific SQL literal for a boolean value.
+func (sqlbldr *Builder) getBoolLiteral( aValue bool ) string {
+	driverName := sqlbldr.dbMeta().Name
 	switch driverName {
 	case MySQL:
-		sqlbldr.StartWith("1")
-	case PostgreSQL:
-		sqlbldr.StartWith("true")
+		if aValue {
+			return "1"
+		}
+		return "0"
 	default:
-		sqlbldr.StartWith("true")
+		if aValue {
+			return "true"
+		}
+		return "false"
 	}//switch
-	return sqlbldr.SetParamPrefix(" AND ")
 }
 
 // SetDataSource Set our param value source.
@@ -162,6 +394,13 @@ func (sqlbldr *Builder) SetDataSource( aDataSource IDataSource ) *Builder {
 	return sqlbldr
 }
 
+// SetSanitizer Set the object used to sanitize field and order by lists so that
+// AddFieldList() and ApplyOrderByList() prune any fields it does not allow.
+func (sqlbldr *Builder) SetSanitizer( aSanitizer ISqlSanitizer ) *Builder {
+	sqlbldr.mySqlSanitizer = aSanitizer
+	return sqlbldr
+}
+
 // SetParam Sets the param value and param type, but does not affect the SQL string.
 func (sqlbldr *Builder) SetParam( aParamKey string, aParamValue string ) *Builder {
 	s := aParamValue
@@ -174,17 +413,68 @@ func (sqlbldr *Builder) SetNullableParam( aParamKey string, aParamValue *string
 	if aParamValue != nil || !sqlbldr.bUseSetNull {
 		sqlbldr.myParams[aParamKey] = aParamValue
 	}
+	delete(sqlbldr.myTypedParams, aParamKey)
+	return sqlbldr
+}
+
+// SetTypedParam Sets the param value keeping its native type (int, bool, time.Time,
+// []byte, etc.) so the driver receives it as-is from SQLargs()/SQLnamedArgs().
+// GetParam() returns the string form of the value. A nil value is the same as
+// SetNullableParam(aParamKey, nil). Does not affect the SQL string.
+func (sqlbldr *Builder) SetTypedParam( aParamKey string, aParamValue interface{} ) *Builder {
+	if aParamValue == nil {
+		return sqlbldr.SetNullableParam(aParamKey, nil)
+	}
+	var s string
+	switch v := aParamValue.(type) {
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprint(v)
+	}//switch
+	sqlbldr.SetNullableParam(aParamKey, &s)
+	sqlbldr.myTypedParams[aParamKey] = aParamValue
 	return sqlbldr
 }
 
+// getParamArg Returns the value to pass to the driver for a non-NULL param.
+func (sqlbldr *Builder) getParamArg( aParamKey string, aParamValue *string ) interface{} {
+	if v, ok := sqlbldr.myTypedParams[aParamKey]; ok {
+		return v
+	}
+	return *aParamValue
+}
+
 // SetParamSet Sets the param value set, but does not affect the SQL string.
 func (sqlbldr *Builder) SetParamSet( aParamKey string, aParamValues *[]string ) *Builder {
 	sqlbldr.myParams[aParamKey] = nil
 	sqlbldr.mySetParams[aParamKey] = aParamValues
+	delete(sqlbldr.myTypedParams, aParamKey)
 	//sqlbldr.myParamTypes[aParamKey] = "string"
 	return sqlbldr
 }
 
+// RemoveParam Removes the param value, or value set, so that it is no longer
+// passed along with the query. The SQL string is not affected.
+func (sqlbldr *Builder) RemoveParam( aParamKey string ) *Builder {
+	delete(sqlbldr.myParams, aParamKey)
+	delete(sqlbldr.mySetParams, aParamKey)
+	delete(sqlbldr.myTypedParams, aParamKey)
+	return sqlbldr
+}
+
+// RemoveParamSet Removes the param value set along with the params its members
+// were expanded into once added to the SQL (e.g. "paramkey_1"). The SQL string
+// is not affected.
+func (sqlbldr *Builder) RemoveParamSet( aParamKey string ) *Builder {
+	if valSet := sqlbldr.GetParamSet(aParamKey); valSet != nil {
+		for i := range *valSet {
+			sqlbldr.RemoveParam(aParamKey + "_" + strconv.Itoa(i+1))
+		}
+	}
+	return sqlbldr.RemoveParam(aParamKey)
+}
+
 // IsParamASet Inquire if the data that will be used for a particular param is a set or not.
 func (sqlbldr *Builder) IsParamASet( aParamKey string ) bool {
 	_, ok := sqlbldr.myParams[aParamKey]
@@ -220,6 +510,34 @@ func (sqlbldr *Builder) GetParamSet( aParamKey string ) *[]string {
 	}
 }
 
+// ParamCount Returns the number of param values our query carries; each member
+// of a value set counts as one param, as it does once expanded into the SQL.
+func (sqlbldr *Builder) ParamCount() int {
+	theCount := 0
+	for k := range sqlbldr.myParams {
+		if _, isSet := sqlbldr.mySetParams[k]; !isSet {
+			theCount += 1
+		}
+	}
+	for k, valSet := range sqlbldr.mySetParams {
+		if valSet == nil {
+			continue
+		}
+		for i := range *valSet {
+			// members already expanded into params were counted above
+			if _, ok := sqlbldr.myParams[k+"_"+strconv.Itoa(i+1)]; !ok {
+				theCount += 1
+			}
+		}
+	}
+	return theCount
+}
+
+// HasParams Returns TRUE if our query carries any param values.
+func (sqlbldr *Builder) HasParams() bool {
+	return sqlbldr.ParamCount() > 0
+}
+
 // GetUniqueParamKey Some SQL drivers require all query parameters be unique.
 // This poses an issue when multiple datakeys with the same name are needed in
 // the query (especially true for MERGE queries). This method will check for any
@@ -242,12 +560,79 @@ func (sqlbldr *Builder) GetUniqueParamKey( aParamKey string ) string {
 // apply to the next AddParam.
 func (sqlbldr *Builder) StartWhereClause() *Builder {
 	sqlbldr.bUseIsNull = true
+	sqlbldr.myWhereStart = len(sqlbldr.mySql)
+	sqlbldr.myWhereSql = nil
+	sqlbldr.myWhereParamKeys = nil
+	sqlbldr.myPreWhereParams = map[string]bool{}
+	for k := range sqlbldr.myParams {
+		sqlbldr.myPreWhereParams[k] = true
+	}
 	return sqlbldr.SetParamPrefix(" WHERE ")
 }
 
 // EndWhereClause Resets the WHERE clause flag.
 func (sqlbldr *Builder) EndWhereClause() *Builder {
 	sqlbldr.bUseIsNull = false
+	sqlbldr.captureWhereClause()
+	return sqlbldr
+}
+
+// Where Builds the WHERE clause with aFunc between calls to StartWhereClause()
+// and EndWhereClause(); the latter is called even should aFunc panic so that
+// the WHERE clause NULL handling never leaks into what follows. e.g.
+// Where(func( b *Builder ) { b.MustAddParam("a").And().MustAddParam("b") })
+func (sqlbldr *Builder) Where( aFunc func( aBuilder *Builder ) ) *Builder {
+	sqlbldr.StartWhereClause()
+	defer sqlbldr.EndWhereClause()
+	aFunc(sqlbldr)
+	return sqlbldr
+}
+
+// captureWhereClause Remember the WHERE clause SQL and the params added for it
+// so that ClearWhere() can remove them later.
+func (sqlbldr *Builder) captureWhereClause() {
+	if sqlbldr.myWhereStart >= 0 && sqlbldr.myWhereSql == nil &&
+		sqlbldr.myWhereStart <= len(sqlbldr.mySql) {
+		theWhereSql := sqlbldr.mySql[sqlbldr.myWhereStart:]
+		sqlbldr.myWhereSql = &theWhereSql
+		for k := range sqlbldr.myParams {
+			if !sqlbldr.myPreWhereParams[k] {
+				sqlbldr.myWhereParamKeys = append(sqlbldr.myWhereParamKeys, k)
+			}
+		}
+	}
+}
+
+// clearWhereTracking Forget any WHERE clause we were tracking.
+func (sqlbldr *Builder) clearWhereTracking() {
+	sqlbldr.myWhereStart = -1
+	sqlbldr.myWhereSql = nil
+	sqlbldr.bIsFilter = false
+	sqlbldr.myPreWhereParams = nil
+	sqlbldr.myWhereParamKeys = nil
+}
+
+// ClearWhere Remove the WHERE clause and the params that were added for it,
+// preserving the rest of the SQL so the SELECT/FROM/JOIN skeleton may be reused.
+// This relies on the clause tracking done by StartWhereClause()/EndWhereClause(),
+// so it only affects a WHERE clause started with StartWhereClause(); if
+// EndWhereClause() was not called, everything since StartWhereClause() is removed.
+// A new WHERE clause will be appended to the end of the SQL as usual, so keep any
+// ORDER BY/LIMIT out of a skeleton that is to be reused this way.
+func (sqlbldr *Builder) ClearWhere() *Builder {
+	sqlbldr.captureWhereClause()
+	if sqlbldr.myWhereSql != nil {
+		if idx := strings.LastIndex(sqlbldr.mySql, *sqlbldr.myWhereSql); idx >= 0 {
+			sqlbldr.mySql = sqlbldr.mySql[:idx] + sqlbldr.mySql[idx+len(*sqlbldr.myWhereSql):]
+		}
+		for _, k := range sqlbldr.myWhereParamKeys {
+			delete(sqlbldr.myParams, k)
+			delete(sqlbldr.mySetParams, k)
+			delete(sqlbldr.myTypedParams, k)
+		}
+	}
+	sqlbldr.bUseIsNull = false
+	sqlbldr.clearWhereTracking()
 	return sqlbldr
 }
 
@@ -281,11 +666,88 @@ func (sqlbldr *Builder) SetParamPrefix( aStr string ) *Builder {
 	return sqlbldr
 }
 
+// And Sets the ParamPrefix "glue" to " AND " for subsequent AddParam kinds of methods.
+func (sqlbldr *Builder) And() *Builder {
+	return sqlbldr.SetParamPrefix(" AND ")
+}
+
+// Or Sets the ParamPrefix "glue" to " OR " for subsequent AddParam kinds of methods.
+func (sqlbldr *Builder) Or() *Builder {
+	return sqlbldr.SetParamPrefix(" OR ")
+}
+
+// OpenParen Starts a parenthesized group, prefixed with the current ParamPrefix
+// "glue". The first param added inside the group gets no glue of its own, so use
+// And()/Or() after it to define how the rest of the group is joined. e.g.
+// StartWhereClause().OpenParen().MustAddParam("a").Or().MustAddParam("b").CloseParen()
+func (sqlbldr *Builder) OpenParen() *Builder {
+	sqlbldr.mySql += sqlbldr.myParamPrefix + "("
+	sqlbldr.myParenPrefixes = append(sqlbldr.myParenPrefixes, sqlbldr.myParamPrefix)
+	return sqlbldr.SetParamPrefix("")
+}
+
+// CloseParen Ends the most recently opened parenthesized group, restoring the
+// ParamPrefix that was in effect when it was opened so that the glue used within
+// the group does not leak out of it. Panics if there is no open group to close.
+func (sqlbldr *Builder) CloseParen() *Builder {
+	n := len(sqlbldr.myParenPrefixes)
+	if n < 1 {
+		panic("CloseParen() called without a matching OpenParen()!")
+	}
+	sqlbldr.mySql += ")"
+	sqlbldr.myParamPrefix = sqlbldr.myParenPrefixes[n-1]
+	sqlbldr.myParenPrefixes = sqlbldr.myParenPrefixes[:n-1]
+	return sqlbldr
+}
+
+// paramState The state affecting how the next param is added; see PushParamState().
+type paramState struct {
+	prefix      string
+	operator    string
+	bUseIsNull  bool
+	bUseSetNull bool
+}
+
+// PushParamState Saves the ParamPrefix and ParamOperator properties along with
+// the WHERE/SET clause NULL handling so that helpers may freely change them and
+// then restore them with PopParamState(). Pushes may be nested.
+func (sqlbldr *Builder) PushParamState() *Builder {
+	sqlbldr.myParamStates = append(sqlbldr.myParamStates, paramState{
+		prefix:      sqlbldr.myParamPrefix,
+		operator:    sqlbldr.myParamOperator,
+		bUseIsNull:  sqlbldr.bUseIsNull,
+		bUseSetNull: sqlbldr.bUseSetNull,
+	})
+	return sqlbldr
+}
+
+// PopParamState Restores the state saved by the matching PushParamState().
+func (sqlbldr *Builder) PopParamState() *Builder {
+	n := len(sqlbldr.myParamStates)
+	if n < 1 {
+		panic("PopParamState() called without a matching PushParamState()!")
+	}
+	theState := sqlbldr.myParamStates[n-1]
+	sqlbldr.myParamStates = sqlbldr.myParamStates[:n-1]
+	sqlbldr.myParamPrefix = theState.prefix
+	sqlbldr.myParamOperator = theState.operator
+	sqlbldr.bUseIsNull = theState.bUseIsNull
+	sqlbldr.bUseSetNull = theState.bUseSetNull
+	return sqlbldr
+}
+
+// reKeywordOperator Operators consisting solely of keywords, e.g. "NOT LIKE".
+var reKeywordOperator = regexp.MustCompile(`^\s*[A-Za-z]+(\s+[A-Za-z]+)*\s*$`)
+
 // SetParamOperator Operator string to use in all subsequent calls to addParam
 // methods. "=" is default, " LIKE " is a popular operator as well.
 func (sqlbldr *Builder) SetParamOperator( aStr string ) *Builder {
 	// "!=" is not standard SQL, but is a common programmer mistake, cnv to "<>"
 	aStr = strings.Replace(aStr, "!=", OPERATOR_NOT_EQUAL, -1)
+	// keyword operators like "not like" need to be spaced out: " NOT LIKE "
+	if reKeywordOperator.MatchString(aStr) {
+		aStr = " " + strings.Join(strings.Fields(strings.ToUpper(aStr)), " ") + " "
+	}
 	sqlbldr.myParamOperator = aStr
 	return sqlbldr
 }
@@ -337,37 +799,160 @@ func (sqlbldr *Builder) isDataKeyDefined( aDataKey string ) bool {
 
 // addParamAsListForColumn Adds to the SQL string as a set of values;
 // e.g. "(:paramkey_1,:paramkey_2,:paramkey_N)"
-// Honors the ParamPrefix and ParamOperator properties.
+// Honors the ParamPrefix and ParamOperator properties. If SetMaxInListSize() was
+// given a limit the set is exceeding, the set is split into several lists that are
+// OR-joined, or AND-joined for a "NOT IN" operator, inside parentheses.
 func (sqlbldr *Builder) addParamAsListForColumn( aColumnName string,
 	aParamKey string, aDataValuesList *[]string,
 ) *Builder {
 	if aDataValuesList != nil && len(*aDataValuesList) > 0 {
-		sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.GetQuoted(aColumnName)
-		sqlbldr.mySql += sqlbldr.myParamOperator + "("
-		i := 1
-		for _, val := range *aDataValuesList {
-			theParamKey := aParamKey + "_" + strconv.Itoa(i)
-			i += 1
-			sqlbldr.mySql += ":" + theParamKey + ","
+		theColumn := sqlbldr.GetQuoted(aColumnName)
+		theChunkSize := len(*aDataValuesList)
+		if sqlbldr.myMaxInListSize > 0 && sqlbldr.myMaxInListSize < theChunkSize {
+			theChunkSize = sqlbldr.myMaxInListSize
+		}
+		var theLists []string
+		theList := ""
+		for i, val := range *aDataValuesList {
+			theParamKey := aParamKey + "_" + strconv.Itoa(i+1)
+			theList += ":" + theParamKey + ","
+			sqlbldr.SetParam(theParamKey, val)
+			if (i+1) % theChunkSize == 0 || i+1 == len(*aDataValuesList) {
+				theLists = append(theLists, theColumn + sqlbldr.myParamOperator + "(" +
+					strings.TrimRight(theList, ",") + ")")
+				theList = ""
+			}
+		}
+		if len(theLists) == 1 {
+			sqlbldr.mySql += sqlbldr.myParamPrefix + theLists[0]
+		} else {
+			theJoiner := " OR "
+			if strings.TrimSpace(sqlbldr.myParamOperator) == "NOT IN" {
+				theJoiner = " AND "
+			}
+			sqlbldr.mySql += sqlbldr.myParamPrefix + "(" + strings.Join(theLists, theJoiner) + ")"
+		}
+	}
+	return sqlbldr
+}
+
+// SetMaxInListSize Databases limit the number of params a statement may use, so a
+// value set larger than aMaxSize is split into several OR-joined IN lists of at
+// most aMaxSize values each. 0, the default, means no limit. This setting is not
+// affected by Reset().
+func (sqlbldr *Builder) SetMaxInListSize( aMaxSize int ) *Builder {
+	sqlbldr.myMaxInListSize = aMaxSize
+	return sqlbldr
+}
+
+// addParamAsGroupForColumn Adds to the SQL string a parenthesized group comparing
+// the column against each value of the set, joined by aJoiner;
+// e.g. "(col LIKE :paramkey_1 OR col LIKE :paramkey_2)"
+// Honors the ParamPrefix and ParamOperator properties.
+func (sqlbldr *Builder) addParamAsGroupForColumn( aColumnName string,
+	aParamKey string, aDataValuesList *[]string, aJoiner string,
+) *Builder {
+	if aDataValuesList != nil && len(*aDataValuesList) > 0 {
+		theColumn := sqlbldr.GetQuoted(aColumnName)
+		theGroup := make([]string, len(*aDataValuesList))
+		for i, val := range *aDataValuesList {
+			theParamKey := aParamKey + "_" + strconv.Itoa(i+1)
+			theGroup[i] = theColumn + sqlbldr.myParamOperator + ":" + theParamKey
 			sqlbldr.SetParam(theParamKey, val)
 		}
-		sqlbldr.mySql = strings.TrimRight(sqlbldr.mySql, ",") + ")"
+		sqlbldr.mySql += sqlbldr.myParamPrefix + "(" + strings.Join(theGroup, aJoiner) + ")"
+	}
+	return sqlbldr
+}
+
+// isNullInDataList Returns TRUE if our data source holds a value list for the key
+// with a NULL in it, see INullableListDataSource.
+func (sqlbldr *Builder) isNullInDataList( aParamKey string ) bool {
+	if theDataSource, ok := sqlbldr.myDataSource.(INullableListDataSource); ok {
+		return theDataSource.IsNullInKeyValueList(aParamKey)
+	}
+	return false
+}
+
+// addParamSetWithNullForColumn Adds the value set of a param whose data list held
+// a NULL along with an explicit NULL test, since "IN (NULL)" never matches;
+// e.g. `("col" IN (:paramkey_1) OR "col" IS NULL)` or, for a "<>" operator,
+// `("col" NOT IN (:paramkey_1) AND "col" IS NOT NULL)`.
+// Honors the ParamPrefix and ParamOperator properties.
+func (sqlbldr *Builder) addParamSetWithNullForColumn( aColumnName string,
+	aParamKey string, aDataValuesList *[]string,
+) *Builder {
+	var theListOp, theJoiner, theNullTest string
+	switch theOp := strings.TrimSpace(sqlbldr.myParamOperator); theOp {
+	case "=", "IN":
+		theListOp, theJoiner, theNullTest = " IN ", " OR ", " IS NULL"
+	case OPERATOR_NOT_EQUAL, "NOT IN":
+		theListOp, theJoiner, theNullTest = " NOT IN ", " AND ", " IS NOT NULL"
+	default:
+		return sqlbldr.addError(fmt.Errorf("sqlBits: operator %q cannot match the NULL in the value set of param %q",
+			theOp, aParamKey))
+	}//switch
+	theNullTest = sqlbldr.GetQuoted(aColumnName) + theNullTest
+	if aDataValuesList == nil || len(*aDataValuesList) == 0 {
+		sqlbldr.mySql += sqlbldr.myParamPrefix + theNullTest
+		return sqlbldr
 	}
+	// build the list on its own so it can be grouped with the NULL test
+	saveParamPrefix, saveParamOp := sqlbldr.myParamPrefix, sqlbldr.myParamOperator
+	sqlbldr.myParamPrefix, sqlbldr.myParamOperator = "", theListOp
+	theListStart := len(sqlbldr.mySql)
+	sqlbldr.addParamAsListForColumn(aColumnName, aParamKey, aDataValuesList)
+	theList := sqlbldr.mySql[theListStart:]
+	sqlbldr.mySql = sqlbldr.mySql[:theListStart]
+	sqlbldr.myParamPrefix, sqlbldr.myParamOperator = saveParamPrefix, saveParamOp
+	sqlbldr.mySql += sqlbldr.myParamPrefix + "(" + theList + theJoiner + theNullTest + ")"
 	return sqlbldr
 }
 
 // addingParam Internal method to affect SQL statment with a param and its value.
 func (sqlbldr *Builder) addingParam( aColName string, aParamKey string ) {
 	isSet := sqlbldr.IsParamASet(aParamKey)
-	if valSet := sqlbldr.GetParamSet(aParamKey); isSet && valSet != nil && len(*valSet) > 0 {
+	if isSet && sqlbldr.isNullInDataList(aParamKey) {
+		sqlbldr.addParamSetWithNullForColumn(aColName, aParamKey, sqlbldr.GetParamSet(aParamKey))
+	} else if valSet := sqlbldr.GetParamSet(aParamKey); isSet && (valSet == nil || len(*valSet) == 0) {
+		// an empty set matches nothing, so IN must be false while NOT IN is true
+		switch theOp := strings.TrimSpace(sqlbldr.myParamOperator); theOp {
+		case "=", "IN", OPERATOR_LIKE, "ILIKE":
+			sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.getBoolLiteral(false)
+		case OPERATOR_NOT_EQUAL, "NOT IN", OPERATOR_NOT_LIKE, "NOT ILIKE":
+			sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.getBoolLiteral(true)
+		default:
+			sqlbldr.addError(fmt.Errorf("sqlBits: operator %q cannot be used with the empty value set of param %q",
+				theOp, aParamKey))
+		}//switch
+	} else if isSet {
 		saveParamOp := sqlbldr.myParamOperator
-		switch strings.TrimSpace(sqlbldr.myParamOperator) {
-		case "=":
+		switch theOp := strings.TrimSpace(sqlbldr.myParamOperator); theOp {
+		case "=", "IN":
 			sqlbldr.myParamOperator = " IN "
-		case OPERATOR_NOT_EQUAL:
+			sqlbldr.addParamAsListForColumn(aColName, aParamKey, valSet)
+		case OPERATOR_NOT_EQUAL, "NOT IN":
 			sqlbldr.myParamOperator = " NOT IN "
+			sqlbldr.addParamAsListForColumn(aColName, aParamKey, valSet)
+		case OPERATOR_LIKE, "ILIKE":
+			// matches if any pattern matches
+			sqlbldr.addParamAsGroupForColumn(aColName, aParamKey, valSet, " OR ")
+		case OPERATOR_NOT_LIKE, "NOT ILIKE":
+			// matches only if no pattern matches
+			sqlbldr.addParamAsGroupForColumn(aColName, aParamKey, valSet, " AND ")
+		case "BETWEEN", "NOT BETWEEN":
+			if len(*valSet) != 2 {
+				sqlbldr.addError(fmt.Errorf("sqlBits: %s requires exactly 2 values for param %q", theOp, aParamKey))
+				break
+			}
+			sqlbldr.SetParam(aParamKey+"_1", (*valSet)[0])
+			sqlbldr.SetParam(aParamKey+"_2", (*valSet)[1])
+			sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.GetQuoted(aColName) + " " + theOp +
+				" :" + aParamKey + "_1 AND :" + aParamKey + "_2"
+		default:
+			sqlbldr.addError(fmt.Errorf("sqlBits: operator %q cannot be used with the value set of param %q",
+				theOp, aParamKey))
 		}//switch
-		sqlbldr.addParamAsListForColumn(aColName, aParamKey, valSet)
 		sqlbldr.myParamOperator = saveParamOp
 	} else {
 		if val := sqlbldr.GetParam(aParamKey); val != nil || !sqlbldr.bUseIsNull {
@@ -388,6 +973,68 @@ func (sqlbldr *Builder) addingParam( aColName string, aParamKey string ) {
 	}
 }
 
+// AddBoolParam Sets the param to the boolean value and adds it to the SQL string
+// for the column. The value is bound in the dialect specific form: MySQL and
+// SQLite store booleans as integers so 1/0 is used while PostgreSQL gets a true
+// boolean; binding the string "true" to a MySQL TINYINT would fail.
+// Honors the ParamPrefix and ParamOperator properties.
+func (sqlbldr *Builder) AddBoolParam( aColumnName string, aParamKey string, aValue bool ) *Builder {
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case MySQL, SQLite:
+		theValue := 0
+		if aValue {
+			theValue = 1
+		}
+		sqlbldr.SetTypedParam(aParamKey, theValue)
+	default:
+		sqlbldr.SetTypedParam(aParamKey, aValue)
+	}//switch
+	sqlbldr.addingParam(aColumnName, aParamKey)
+	return sqlbldr
+}
+
+// AddTupleInParam Adds a composite key comparison of the columns against the
+// rows of values, e.g. `("a", "b") IN ((:a, :b), (:a2, :b2))`, with each value
+// bound to its own param made unique using GetUniqueParamKey(). A "<>" operator
+// emits NOT IN. With no rows, it is the same as an empty value set: false for IN
+// and true for NOT IN. Honors the ParamPrefix and ParamOperator properties.
+func (sqlbldr *Builder) AddTupleInParam( aColumns []string, aRows [][]string ) *Builder {
+	theOp := " IN "
+	switch strings.TrimSpace(sqlbldr.myParamOperator) {
+	case OPERATOR_NOT_EQUAL, "NOT IN":
+		theOp = " NOT IN "
+	}//switch
+	if len(aRows) == 0 {
+		sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.getBoolLiteral(theOp == " NOT IN ")
+		return sqlbldr
+	}
+	theColumns := make([]string, len(aColumns))
+	for i, theColumn := range aColumns {
+		theColumns[i] = sqlbldr.GetQuotedQualified(theColumn)
+	}
+	// check every row before setting any param so a bad row leaves none behind
+	for r, theRow := range aRows {
+		if len(theRow) != len(aColumns) {
+			return sqlbldr.addError(fmt.Errorf("sqlBits: tuple row %d has %d values for %d columns",
+				r+1, len(theRow), len(aColumns)))
+		}
+	}
+	theTuples := make([]string, len(aRows))
+	for r, theRow := range aRows {
+		theParams := make([]string, len(theRow))
+		for i, theValue := range theRow {
+			theParamKey := sqlbldr.GetUniqueParamKey(strings.Replace(aColumns[i], ".", "_", -1))
+			sqlbldr.SetParam(theParamKey, theValue)
+			theParams[i] = ":" + theParamKey
+		}
+		theTuples[r] = "(" + strings.Join(theParams, ", ") + ")"
+	}
+	sqlbldr.mySql += sqlbldr.myParamPrefix + "(" + strings.Join(theColumns, ", ") + ")" + theOp +
+		"(" + strings.Join(theTuples, ", ") + ")"
+	return sqlbldr
+}
+
 // AppendParam Parameter must go into the SQL string regardless of NULL status of data.
 func (sqlbldr *Builder) AppendParam( aParamKey string, aParamValue string ) *Builder {
 	sqlbldr.SetParam(aParamKey, aParamValue)
@@ -426,84 +1073,1107 @@ func (sqlbldr *Builder) AddParamForColumnIfDefined( aParamKey string, aColumnNam
 	return sqlbldr
 }
 
-// AddFieldList Adds the list of fields (columns) to the SQL string.
-func (sqlbldr *Builder) AddFieldList( aFieldList *[]string ) *Builder {
-	theFieldListStr := sqlbldr.myParamPrefix + "*"
-	if aFieldList != nil && len(*aFieldList) > 0 {
-		theFieldListStr = sqlbldr.myParamPrefix +
-			strings.Join(*aFieldList, ", "+sqlbldr.myParamPrefix)
+// AddParamsFromStruct Adds a param for each non-nil pointer field of the filter
+// struct, e.g. `"status"=:status AND "owner_id"=:owner_id`, so that optional
+// filters may be declared as a struct whose unset fields are simply left nil.
+// Fields map to columns by the same rules as DetermineFieldsFromTableStruct and
+// the column name is used as the param key. A *[]string field is added as a value
+// set while other values keep their native type. aFilterStruct may be a struct or
+// a pointer to one. The first param added uses the current ParamPrefix, e.g. right
+// after StartWhereClause(), and the ParamPrefix is then set to " AND ".
+// Honors the ParamOperator property.
+func (sqlbldr *Builder) AddParamsFromStruct( aFilterStruct interface{} ) *Builder {
+	theValue := reflect.ValueOf(aFilterStruct)
+	for theValue.Kind() == reflect.Ptr && !theValue.IsNil() {
+		theValue = theValue.Elem()
 	}
-	return sqlbldr.Add(theFieldListStr)
+	return sqlbldr.addParamsFromStructValue(theValue)
 }
 
-// AddQueryLimit Return the SQL "LIMIT" expression for our model's database type.
-func (sqlbldr *Builder) AddQueryLimit( aLimit int, aOffset int ) *Builder {
-	if aLimit > 0 && sqlbldr.myDbModel != nil {
-		driverName := sqlbldr.myDbModel.GetDbMeta().Name
-		switch driverName {
-		case MySQL:
-		case PostgreSQL:
-		default:
-			sqlbldr.Add("LIMIT").Add(strconv.Itoa(aLimit))
-			if aOffset > 0 {
-				sqlbldr.Add("OFFSET").Add(strconv.Itoa(aOffset))
+// addParamsFromStructValue Adds a param for each non-nil pointer field of the struct.
+func (sqlbldr *Builder) addParamsFromStructValue( aStructValue reflect.Value ) *Builder {
+	if aStructValue.Kind() != reflect.Struct {
+		return sqlbldr
+	}
+	theStructType := aStructValue.Type()
+	for i:=0; i<theStructType.NumField(); i++ {
+		theField := theStructType.Field(i)
+		if !IsStructFieldExported(theField) {
+			continue
+		}
+		theFieldValue := aStructValue.Field(i)
+		if getNestedStructType(theField) != nil {
+			for theFieldValue.Kind() == reflect.Ptr && !theFieldValue.IsNil() {
+				theFieldValue = theFieldValue.Elem()
 			}
-		}//switch
+			sqlbldr.addParamsFromStructValue(theFieldValue)
+			continue
+		}
+		theColumnName := GetQueryFieldNameOfStructField(theField)
+		if theColumnName == "-" || theFieldValue.Kind() != reflect.Ptr || theFieldValue.IsNil() {
+			continue
+		}
+		if valSet, ok := theFieldValue.Interface().(*[]string); ok {
+			sqlbldr.SetParamSet(theColumnName, valSet)
+		} else {
+			sqlbldr.SetTypedParam(theColumnName, theFieldValue.Elem().Interface())
+		}
+		sqlbldr.addingParam(theColumnName, theColumnName)
+		sqlbldr.And()
 	}
 	return sqlbldr
 }
 
-// AddSubQueryForColumn Sub-query gets added to the SQL string.
-func (sqlbldr *Builder) AddSubQueryForColumn( aSubQuery *Builder, aColumnName string ) *Builder {
-	saveParamOp := sqlbldr.myParamOperator
-	switch strings.TrimSpace(sqlbldr.myParamOperator) {
-	case "=":
-		sqlbldr.myParamOperator = " IN "
-	case OPERATOR_NOT_EQUAL:
-		sqlbldr.myParamOperator = " NOT IN "
-	}//switch
-	sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.GetQuoted(aColumnName) +
-		sqlbldr.myParamOperator + "(" + aSubQuery.mySql + ")"
-	sqlbldr.myParamOperator = saveParamOp
-	//also merge in any params from the sub-query
-	for k, v := range aSubQuery.myParams {
-		sqlbldr.myParams[k] = v
-	}
-	for k, v := range aSubQuery.mySetParams {
-		sqlbldr.mySetParams[k] = v
+// AddParamEmptyAsNull Same as MustAddParamForColumn() except that an empty string
+// value, as web forms submit for fields left blank, is bound as NULL instead. So
+// that NULL is handled as usual; `"col" IS NULL` in a WHERE clause, see
+// StartWhereClause(), and `"col"=NULL` in a SET clause, see StartSetClause().
+func (sqlbldr *Builder) AddParamEmptyAsNull( aColumnName string, aParamKey string ) *Builder {
+	sqlbldr.getParamValueFromDataSource(aParamKey)
+	if val := sqlbldr.GetParam(aParamKey); val != nil && *val == "" && !sqlbldr.IsParamASet(aParamKey) {
+		sqlbldr.RemoveParam(aParamKey)
+		sqlbldr.SetNullableParam(aParamKey, nil)
 	}
+	sqlbldr.addingParam(aColumnName, aParamKey)
 	return sqlbldr
 }
 
-// ApplyFilter Apply an externally defined set of WHERE field clauses and param
-// values to our SQL (excludes the "WHERE" keyword).
-func (sqlbldr *Builder) ApplyFilter( aFilter *Builder ) *Builder {
-	if aFilter != nil {
-		if aFilter.mySql != "" {
-			sqlbldr.mySql += sqlbldr.myParamPrefix + aFilter.mySql
-		}
-		//also merge in any params from the sub-query
-		for k, v := range aFilter.myParams {
-			sqlbldr.myParams[k] = v
-		}
-		for k, v := range aFilter.mySetParams {
-			sqlbldr.mySetParams[k] = v
+// AddParamAsArray Adds the param value set to the SQL string as a single array
+// param, `"col" = ANY(:paramkey)`, rather than expanding it into an IN list of
+// one param per value as MustAddParamForColumn does; this avoids running into the
+// driver's param limit with large sets. A "<>" operator emits `"col" <> ALL(:paramkey)`.
+// Only PostgreSQL supports array params, other dialects fall back to the IN list.
+// The set is bound as an array literal, e.g. `{"a","b"}`, which PostgreSQL casts
+// to the array type of the column. Honors the ParamPrefix and ParamOperator properties.
+func (sqlbldr *Builder) AddParamAsArray( aColumnName string, aParamKey string ) *Builder {
+	sqlbldr.getParamValueFromDataSource(aParamKey)
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case PostgreSQL:
+		var theValues []string
+		if valSet := sqlbldr.GetParamSet(aParamKey); valSet != nil {
+			theValues = *valSet
+		} else if val := sqlbldr.GetParam(aParamKey); val != nil {
+			theValues = []string{*val}
 		}
-	}
+		delete(sqlbldr.mySetParams, aParamKey)
+		sqlbldr.SetParam(aParamKey, getArrayLiteral(theValues))
+		theExpr := " = ANY(:" + aParamKey + ")"
+		switch strings.TrimSpace(sqlbldr.myParamOperator) {
+		case OPERATOR_NOT_EQUAL, "NOT IN":
+			theExpr = " <> ALL(:" + aParamKey + ")"
+		}//switch
+		sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.GetQuoted(aColumnName) + theExpr
+	default:
+		sqlbldr.addingParam(aColumnName, aParamKey)
+	}//switch
 	return sqlbldr
 }
 
-// ApplySortList If sort list is defined and its contents are also contained
-// in the non-empty $aFieldList, then apply the sort order as neccessary.
-// @see ApplyOrderByList() which this method is an alias of.
-func (sqlbldr *Builder) ApplySortList( aSortList *OrderByList ) *Builder {
-	return sqlbldr.ApplyOrderByList(aSortList)
+// getArrayLiteral Returns the PostgreSQL array literal of the values, e.g. `{"a","b"}`.
+func getArrayLiteral( aValues []string ) string {
+	theElems := make([]string, len(aValues))
+	for i, theValue := range aValues {
+		theValue = strings.Replace(theValue, `\`, `\\`, -1)
+		theElems[i] = `"` + strings.Replace(theValue, `"`, `\"`, -1) + `"`
+	}
+	return "{" + strings.Join(theElems, ",") + "}"
 }
 
-// ApplyOrderByList If order by list is defined, then apply the sort order as neccessary.
-func (sqlbldr *Builder) ApplyOrderByList( aOrderByList *OrderByList ) *Builder {
-	if aOrderByList != nil && sqlbldr.myDbModel != nil {
-		theSortKeyword := "ORDER BY"
+// AddCaseInsensitiveLikeParam Adds a case-insensitive LIKE comparison for the
+// column using the dialect specific form; PostgreSQL uses "ILIKE" while MySQL and
+// SQLite compare both sides wrapped in "LOWER()". Honors the ParamPrefix property.
+func (sqlbldr *Builder) AddCaseInsensitiveLikeParam( aColumnName string, aParamKey string ) *Builder {
+	sqlbldr.getParamValueFromDataSource(aParamKey)
+	theColumn := sqlbldr.GetQuoted(aColumnName)
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case PostgreSQL:
+		sqlbldr.mySql += sqlbldr.myParamPrefix + theColumn + " ILIKE :" + aParamKey
+	default:
+		sqlbldr.mySql += sqlbldr.myParamPrefix + "LOWER(" + theColumn + ") LIKE LOWER(:" + aParamKey + ")"
+	}//switch
+	return sqlbldr
+}
+
+// AddParamNullSafeEquals Adds a NULL-safe equality comparison of the column and
+// param so that NULL matches NULL, evaluated at runtime unlike the IS NULL handling
+// of a WHERE clause which only applies to values known to be NULL while building
+// the SQL. MySQL uses "<=>" while PostgreSQL and SQLite use "IS NOT DISTINCT FROM".
+// The ParamOperator property is ignored, see AddParamNullSafeNotEquals() for the
+// negated comparison. Honors the ParamPrefix property.
+func (sqlbldr *Builder) AddParamNullSafeEquals( aColumnName string, aParamKey string ) *Builder {
+	return sqlbldr.addParamNullSafeCompare(aColumnName, aParamKey, false)
+}
+
+// AddNullSafeEqualParam Alias of AddParamNullSafeEquals().
+func (sqlbldr *Builder) AddNullSafeEqualParam( aColumnName string, aParamKey string ) *Builder {
+	return sqlbldr.AddParamNullSafeEquals(aColumnName, aParamKey)
+}
+
+// AddParamNullSafeNotEquals Adds the negation of AddParamNullSafeEquals() so that
+// NULL never differs from NULL: MySQL uses "NOT (col <=> :param)" while PostgreSQL
+// and SQLite use "IS DISTINCT FROM". The ParamOperator property is ignored.
+// Honors the ParamPrefix property.
+func (sqlbldr *Builder) AddParamNullSafeNotEquals( aColumnName string, aParamKey string ) *Builder {
+	return sqlbldr.addParamNullSafeCompare(aColumnName, aParamKey, true)
+}
+
+// addParamNullSafeCompare Adds the dialect's NULL-safe comparison of the column
+// and param, negated if bNegate is TRUE.
+func (sqlbldr *Builder) addParamNullSafeCompare( aColumnName string, aParamKey string, bNegate bool ) *Builder {
+	sqlbldr.getParamValueFromDataSource(aParamKey)
+	theColumn := sqlbldr.GetQuoted(aColumnName)
+	var theExpr string
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case MySQL:
+		theExpr = theColumn + " <=> :" + aParamKey
+		if bNegate {
+			theExpr = "NOT (" + theExpr + ")"
+		}
+	default:
+		if bNegate {
+			theExpr = theColumn + " IS DISTINCT FROM :" + aParamKey
+		} else {
+			theExpr = theColumn + " IS NOT DISTINCT FROM :" + aParamKey
+		}
+	}//switch
+	sqlbldr.mySql += sqlbldr.myParamPrefix + theExpr
+	return sqlbldr
+}
+
+// AddExpressionParam Adds a raw SQL expression to the SQL string with each "?"
+// marker in it, outside of quotes, replaced by the param; e.g. the expression
+// "lower(name) = lower(?)" becomes "lower(name) = lower(:paramkey)". The param
+// value is bound the same as for MustAddParam(). Since the expression is added
+// as-is, it must never contain user input. Honors the ParamPrefix property.
+func (sqlbldr *Builder) AddExpressionParam( aExpression string, aParamKey string ) *Builder {
+	var theExpr strings.Builder
+	var theQuote byte
+	bHasMarker := false
+	for i := 0; i < len(aExpression); i++ {
+		c := aExpression[i]
+		if theQuote != 0 {
+			if c == theQuote {
+				theQuote = 0
+			}
+		} else if c == '\'' || c == '"' || c == '`' {
+			theQuote = c
+		} else if c == '?' {
+			theExpr.WriteString(":" + aParamKey)
+			bHasMarker = true
+			continue
+		}
+		theExpr.WriteByte(c)
+	}
+	if !bHasMarker {
+		return sqlbldr.addError(fmt.Errorf("sqlBits: expression %q has no \"?\" marker for param %q",
+			aExpression, aParamKey))
+	}
+	sqlbldr.getParamValueFromDataSource(aParamKey)
+	sqlbldr.mySql += sqlbldr.myParamPrefix + theExpr.String()
+	return sqlbldr
+}
+
+// AddFieldList Adds the list of fields (columns) to the SQL string.
+// Field names are quoted if SetFieldListQuoting(true) was called and pruned by
+// the sanitizer if one was set with SetSanitizer(). Should the sanitizer prune
+// every field, its defined fields are used rather than "*" so that the query
+// never selects more than the sanitizer allows.
+func (sqlbldr *Builder) AddFieldList( aFieldList *[]string ) *Builder {
+	theFieldListStr := sqlbldr.myParamPrefix + "*"
+	if aFieldList != nil && len(*aFieldList) > 0 && sqlbldr.mySqlSanitizer != nil {
+		theSanitizedList := sqlbldr.mySqlSanitizer.GetSanitizedFieldList(*aFieldList)
+		if len(theSanitizedList) == 0 {
+			theSanitizedList = sqlbldr.mySqlSanitizer.GetDefinedFields()
+			if len(theSanitizedList) == 0 {
+				return sqlbldr.addError(fmt.Errorf("sqlBits: the sanitizer pruned every field of %v",
+					*aFieldList))
+			}
+		}
+		aFieldList = &theSanitizedList
+	}
+	if aFieldList != nil && len(*aFieldList) > 0 {
+		theFieldList := *aFieldList
+		if sqlbldr.bQuoteFieldList {
+			theFieldList = make([]string, len(*aFieldList))
+			for i, theField := range *aFieldList {
+				theFieldList[i] = sqlbldr.GetQuoted(theField)
+			}
+		}
+		theFieldListStr = sqlbldr.myParamPrefix +
+			strings.Join(theFieldList, ", "+sqlbldr.myParamPrefix)
+	}
+	return sqlbldr.Add(theFieldListStr)
+}
+
+// SetFieldListQuoting Determines if AddFieldList() quotes the field names it adds.
+// This setting is not affected by Reset().
+func (sqlbldr *Builder) SetFieldListQuoting( aQuoteFields bool ) *Builder {
+	sqlbldr.bQuoteFieldList = aQuoteFields
+	return sqlbldr
+}
+
+// AddFieldListWithAliases Adds the fields (columns) to the SQL string, each quoted
+// and aliased as its quoted map value, e.g. "col" AS "alias"; an empty alias means
+// the field is not aliased. Fields are added in sorted order so the SQL is
+// consistent; an empty map adds "*" just like AddFieldList(). Fields are pruned
+// by the sanitizer if one was set with SetSanitizer(); should it prune every
+// field, AddFieldList() decides what to add instead.
+func (sqlbldr *Builder) AddFieldListWithAliases( aFields map[string]string ) *Builder {
+	theFieldNames := make([]string, 0, len(aFields))
+	for k := range aFields {
+		theFieldNames = append(theFieldNames, k)
+	}
+	if len(theFieldNames) == 0 {
+		return sqlbldr.AddFieldList(nil)
+	}
+	if sqlbldr.mySqlSanitizer != nil {
+		theSanitizedNames := sqlbldr.mySqlSanitizer.GetSanitizedFieldList(theFieldNames)
+		if len(theSanitizedNames) == 0 {
+			return sqlbldr.AddFieldList(&theFieldNames)
+		}
+		theFieldNames = theSanitizedNames
+	}
+	sort.Strings(theFieldNames)
+	theFieldList := make([]string, len(theFieldNames))
+	for i, theFieldName := range theFieldNames {
+		theFieldList[i] = sqlbldr.GetQuoted(theFieldName)
+		if theAlias := aFields[theFieldName]; theAlias != "" {
+			theFieldList[i] += " AS " + sqlbldr.GetQuoted(theAlias)
+		}
+	}
+	return sqlbldr.Add(sqlbldr.myParamPrefix + strings.Join(theFieldList, ", "+sqlbldr.myParamPrefix))
+}
+
+// AddConcatField Adds a field to the SQL string that is the concatenation of aParts
+// using the dialect specific form; MySQL uses "CONCAT(a, b)" while PostgreSQL and
+// SQLite use "a || b". Parts are quoted as column names unless they start with ":"
+// in which case they are emitted as a param (e.g. a bound " " separator set via
+// SetParam()). Honors the ParamPrefix property; aAlias is optional.
+func (sqlbldr *Builder) AddConcatField( aParts []string, aAlias string ) *Builder {
+	if len(aParts) > 0 {
+		theParts := make([]string, len(aParts))
+		for i, thePart := range aParts {
+			if strings.HasPrefix(thePart, ":") {
+				theParts[i] = thePart
+			} else {
+				theParts[i] = sqlbldr.GetQuoted(thePart)
+			}
+		}
+		var theExpr string
+		driverName := sqlbldr.dbMeta().Name
+		switch driverName {
+		case MySQL:
+			theExpr = "CONCAT(" + strings.Join(theParts, ", ") + ")"
+		default:
+			theExpr = strings.Join(theParts, " || ")
+		}//switch
+		if aAlias != "" {
+			theExpr += " AS " + sqlbldr.GetQuoted(aAlias)
+		}
+		sqlbldr.mySql += sqlbldr.myParamPrefix + theExpr
+	}
+	return sqlbldr
+}
+
+// WindowFunction Defines a window function field, e.g. ROW_NUMBER() OVER (...).
+type WindowFunction struct {
+	// The function call as-is, e.g. "ROW_NUMBER()", "RANK()", or "sum(amount)";
+	// it is not quoted, so it must never contain user input.
+	Func string
+	// Columns to partition the rows by.
+	PartitionBy []string
+	// Columns to order the rows of a partition by, optionally followed by their
+	// direction, e.g. "created_at DESC".
+	OrderBy []string
+	// The field name of the result.
+	Alias string
+}
+
+// AddWindowField Adds the window function as a field to the SQL string, e.g.
+// `ROW_NUMBER() OVER (PARTITION BY "a" ORDER BY "b" DESC) AS "rn"`, quoting the
+// partition and order columns. MySQL only supports window functions as of 8.0.
+// Honors the ParamPrefix property.
+func (sqlbldr *Builder) AddWindowField( aWindow WindowFunction ) *Builder {
+	var theOver []string
+	if len(aWindow.PartitionBy) > 0 {
+		theColumns := make([]string, len(aWindow.PartitionBy))
+		for i, theColumn := range aWindow.PartitionBy {
+			theColumns[i] = sqlbldr.GetQuotedQualified(theColumn)
+		}
+		theOver = append(theOver, "PARTITION BY " + strings.Join(theColumns, ", "))
+	}
+	if len(aWindow.OrderBy) > 0 {
+		var theColumns []string
+		for _, theEntry := range aWindow.OrderBy {
+			if theWords := strings.Fields(theEntry); len(theWords) > 0 {
+				theColumns = append(theColumns, sqlbldr.GetQuotedQualified(theWords[0]) + " " +
+					sqlbldr.getOrderByDirection(strings.Join(theWords[1:], " ")))
+			}
+		}
+		theOver = append(theOver, "ORDER BY " + strings.Join(theColumns, ", "))
+	}
+	theExpr := aWindow.Func + " OVER (" + strings.Join(theOver, " ") + ")"
+	if aWindow.Alias != "" {
+		theExpr += " AS " + sqlbldr.GetQuoted(aWindow.Alias)
+	}
+	sqlbldr.mySql += sqlbldr.myParamPrefix + theExpr
+	return sqlbldr
+}
+
+// AddCoalesceField Adds the column as a select field that falls back to the value
+// of the param if the column is NULL, e.g. `COALESCE("col", :default) AS "alias"`;
+// MySQL uses the equivalent IFNULL() instead. Honors the ParamPrefix property.
+func (sqlbldr *Builder) AddCoalesceField( aColumnName string, aDefaultParamKey string, aAlias string ) *Builder {
+	sqlbldr.getParamValueFromDataSource(aDefaultParamKey)
+	theArgs := sqlbldr.GetQuoted(aColumnName) + ", :" + aDefaultParamKey
+	var theExpr string
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case MySQL:
+		theExpr = "IFNULL(" + theArgs + ")"
+	default:
+		theExpr = "COALESCE(" + theArgs + ")"
+	}//switch
+	if aAlias != "" {
+		theExpr += " AS " + sqlbldr.GetQuoted(aAlias)
+	}
+	sqlbldr.mySql += sqlbldr.myParamPrefix + theExpr
+	return sqlbldr
+}
+
+// reJsonPathPart JSON path parts must be simple keys or array indexes.
+var reJsonPathPart = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// getJsonPathParts Returns the keys/indexes of aJsonPath, e.g. "a.b" or "$.a.b".
+func getJsonPathParts( aJsonPath string ) ([]string, error) {
+	theParts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(aJsonPath, "$"), "."), ".")
+	for _, thePart := range theParts {
+		if !reJsonPathPart.MatchString(thePart) {
+			return nil, fmt.Errorf("sqlBits: invalid JSON path %q", aJsonPath)
+		}
+	}
+	return theParts, nil
+}
+
+// getSqlJsonPath Returns the SQL/JSON path of the parts, e.g. "$.a[0].b".
+func getSqlJsonPath( aParts []string ) string {
+	thePath := "$"
+	for _, thePart := range aParts {
+		if _, err := strconv.Atoi(thePart); err == nil {
+			thePath += "[" + thePart + "]"
+		} else {
+			thePath += "." + thePart
+		}
+	}
+	return thePath
+}
+
+// getJsonExtractExpr Returns the dialect specific SQL expression to extract the
+// text value found at aJsonPath (e.g. "a.b" or "$.a.b") inside the JSON column.
+func (sqlbldr *Builder) getJsonExtractExpr( aColumnName string, aJsonPath string ) (string, error) {
+	theParts, err := getJsonPathParts(aJsonPath)
+	if err != nil {
+		return "", err
+	}
+	theColumn := sqlbldr.GetQuoted(aColumnName)
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case PostgreSQL:
+		if _, err := strconv.Atoi(theParts[0]); len(theParts) == 1 && err != nil {
+			return theColumn + "->>'" + theParts[0] + "'", nil
+		}
+		return theColumn + "#>>'{" + strings.Join(theParts, ",") + "}'", nil
+	case MySQL, SQLite:
+		thePath := getSqlJsonPath(theParts)
+		if driverName == MySQL {
+			return "JSON_UNQUOTE(JSON_EXTRACT(" + theColumn + ", '" + thePath + "'))", nil
+		}
+		if !sqlbldr.dbMeta().SupportsJSON1 {
+			return "", fmt.Errorf("sqlBits: JSON extraction requires the SQLite JSON1 extension")
+		}
+		return "json_extract(" + theColumn + ", '" + thePath + "')", nil
+	default:
+		return "", fmt.Errorf("sqlBits: JSON extraction is not supported for driver %q", driverName)
+	}//switch
+}
+
+// AddJsonField Adds a field to the SQL string that extracts the value at
+// aJsonPath (e.g. "address.city") from the JSON column using the dialect specific
+// syntax. Honors the ParamPrefix property; aAlias is optional. An error is
+// recorded (see GetErrors()) for an invalid path or an unsupported dialect.
+func (sqlbldr *Builder) AddJsonField( aColumnName string, aJsonPath string, aAlias string ) *Builder {
+	theExpr, err := sqlbldr.getJsonExtractExpr(aColumnName, aJsonPath)
+	if err != nil {
+		return sqlbldr.addError(err)
+	}
+	if aAlias != "" {
+		theExpr += " AS " + sqlbldr.GetQuoted(aAlias)
+	}
+	sqlbldr.mySql += sqlbldr.myParamPrefix + theExpr
+	return sqlbldr
+}
+
+// AddParamJsonPath Adds a comparison of the value at aJsonPath (e.g. "address.city")
+// inside the JSON column against the param. Honors the ParamPrefix and
+// ParamOperator properties. An error is recorded (see GetErrors()) for an invalid
+// path or an unsupported dialect.
+func (sqlbldr *Builder) AddParamJsonPath( aColumnName string, aJsonPath string, aParamKey string ) *Builder {
+	theExpr, err := sqlbldr.getJsonExtractExpr(aColumnName, aJsonPath)
+	if err != nil {
+		return sqlbldr.addError(err)
+	}
+	sqlbldr.getParamValueFromDataSource(aParamKey)
+	sqlbldr.mySql += sqlbldr.myParamPrefix + theExpr + sqlbldr.myParamOperator + ":" + aParamKey
+	return sqlbldr
+}
+
+// AddJsonExtractParam Adds a MySQL comparison of the JSON value at aJsonPath
+// (e.g. "address.city") inside the JSON column against the param, e.g.
+// "JSON_EXTRACT(`data`, '$.address.city') = :city". Unlike AddParamJsonPath(),
+// the value is not unquoted, so it is compared as JSON; bind numbers and booleans
+// with SetTypedParam() for them to match. Honors the ParamPrefix and ParamOperator
+// properties. An error is recorded (see GetErrors()) for an invalid path or
+// other dialects.
+func (sqlbldr *Builder) AddJsonExtractParam( aColumnName string, aJsonPath string, aParamKey string ) *Builder {
+	driverName := sqlbldr.dbMeta().Name
+	if driverName != MySQL {
+		return sqlbldr.addError(fmt.Errorf("sqlBits: JSON_EXTRACT is not supported for driver %q", driverName))
+	}
+	theParts, err := getJsonPathParts(aJsonPath)
+	if err != nil {
+		return sqlbldr.addError(err)
+	}
+	sqlbldr.getParamValueFromDataSource(aParamKey)
+	sqlbldr.mySql += sqlbldr.myParamPrefix + "JSON_EXTRACT(" + sqlbldr.GetQuoted(aColumnName) + ", '" +
+		getSqlJsonPath(theParts) + "')" + sqlbldr.myParamOperator + ":" + aParamKey
+	return sqlbldr
+}
+
+// AddJsonbContains Adds a PostgreSQL JSONB containment test of the column against
+// the JSON document held by the param, e.g. `"data" @> CAST(:filter AS jsonb)`
+// with the param set to `{"status":"active"}`. Honors the ParamPrefix property.
+// An error is recorded (see GetErrors()) for other dialects.
+func (sqlbldr *Builder) AddJsonbContains( aColumnName string, aParamKey string ) *Builder {
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case PostgreSQL:
+		sqlbldr.getParamValueFromDataSource(aParamKey)
+		sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.GetQuoted(aColumnName) +
+			" @> CAST(:" + aParamKey + " AS jsonb)"
+	default:
+		sqlbldr.addError(fmt.Errorf("sqlBits: JSONB containment is not supported for driver %q", driverName))
+	}//switch
+	return sqlbldr
+}
+
+// AddQueryLimit Return the SQL "LIMIT" expression for our model's database type.
+func (sqlbldr *Builder) AddQueryLimit( aLimit int, aOffset int ) *Builder {
+	if aLimit > 0 && sqlbldr.myDbModel != nil {
+		driverName := sqlbldr.dbMeta().Name
+		switch driverName {
+		case MySQL:
+		case PostgreSQL:
+		default:
+			sqlbldr.Add("LIMIT").Add(strconv.Itoa(aLimit))
+			if aOffset > 0 {
+				sqlbldr.Add("OFFSET").Add(strconv.Itoa(aOffset))
+			}
+		}//switch
+	}
+	return sqlbldr
+}
+
+// AddInsertValues Adds to the SQL string the quoted column list followed by a
+// VALUES row for each data source; e.g. after StartWith("INSERT INTO t"):
+// `("a", "b") VALUES (:a, :b), (:a2, :b2)` with params made unique using
+// GetUniqueParamKey(). See BuildBatchInserts() to keep within param limits.
+func (sqlbldr *Builder) AddInsertValues( aColumns []string, aRows []IDataSource ) *Builder {
+	if len(aColumns) == 0 || len(aRows) == 0 {
+		return sqlbldr
+	}
+	theColumns := make([]string, len(aColumns))
+	for i, theColumn := range aColumns {
+		theColumns[i] = sqlbldr.GetQuoted(theColumn)
+	}
+	theRows := make([]string, len(aRows))
+	for r, theRow := range aRows {
+		theValues := make([]string, len(aColumns))
+		for i, theColumn := range aColumns {
+			var theValue *string
+			if theRow != nil {
+				theValue = theRow.GetValueForKey(theColumn)
+			}
+			if theValue == nil && sqlbldr.bUseSetNull {
+				theValues[i] = "NULL"
+				continue
+			}
+			theParamKey := sqlbldr.GetUniqueParamKey(theColumn)
+			sqlbldr.SetNullableParam(theParamKey, theValue)
+			theValues[i] = ":" + theParamKey
+		}
+		theRows[r] = "(" + strings.Join(theValues, ", ") + ")"
+	}
+	sqlbldr.mySql += " (" + strings.Join(theColumns, ", ") + ") VALUES " + strings.Join(theRows, ", ")
+	return sqlbldr
+}
+
+// BuildBatchInserts Returns new Builders, each with a multi-row INSERT into the
+// table for a batch of the rows; see AddInsertValues(). Each statement binds at
+// most the MaxBindParams of the driver, else all rows are inserted by a single
+// statement should the driver have no known limit.
+func (sqlbldr *Builder) BuildBatchInserts( aTableName string, aColumns []string,
+	aRows []IDataSource,
+) []*Builder {
+	var theBatches []*Builder
+	theBatchSize := len(aRows)
+	if theMaxParams := sqlbldr.dbMeta().MaxBindParams; theMaxParams > 0 && len(aColumns) > 0 {
+		theBatchSize = theMaxParams / len(aColumns)
+		if theBatchSize < 1 {
+			theBatchSize = 1
+		}
+	}
+	for theStart := 0; theStart < len(aRows); theStart += theBatchSize {
+		theEnd := theStart + theBatchSize
+		if theEnd > len(aRows) {
+			theEnd = len(aRows)
+		}
+		theNewBuilder := *sqlbldr
+		theNewBuilder.Reset().StartWith("INSERT INTO " + sqlbldr.GetQuotedQualified(aTableName))
+		theBatches = append(theBatches, theNewBuilder.AddInsertValues(aColumns, aRows[theStart:theEnd]))
+	}
+	return theBatches
+}
+
+// AddForUpdate Adds the row locking clause, "FOR UPDATE", so that the rows read
+// are locked until the end of the transaction. Since SQLite has no row locks,
+// nothing is added for it. Call it last, after any LIMIT has been added.
+func (sqlbldr *Builder) AddForUpdate() *Builder {
+	return sqlbldr.addRowLocking("FOR UPDATE")
+}
+
+// AddForUpdateSkipLocked Adds the row locking clause, "FOR UPDATE SKIP LOCKED",
+// so that rows already locked by others are skipped rather than waited on.
+// Since SQLite has no row locks, nothing is added for it. Call it last, after
+// any LIMIT has been added.
+func (sqlbldr *Builder) AddForUpdateSkipLocked() *Builder {
+	return sqlbldr.addRowLocking("FOR UPDATE SKIP LOCKED")
+}
+
+// addRowLocking Adds the locking clause unless the database lacks row locks.
+func (sqlbldr *Builder) addRowLocking( aLockingClause string ) *Builder {
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case SQLite:
+	default:
+		sqlbldr.Add(aLockingClause)
+	}//switch
+	return sqlbldr
+}
+
+// AddColumnCompare Adds a comparison of one column to another rather than to a
+// param, e.g. `"a"."id" = "b"."a_id"`, for self-joins and correlated filters.
+// Both column names are quoted, schema/table qualified names included, and aOperator
+// must be one of "=", "<>", "!=", "<", "<=", ">", or ">=" else an error is recorded
+// instead (see GetErrors()). Honors the ParamPrefix property.
+func (sqlbldr *Builder) AddColumnCompare( aLeftColumn string, aOperator string, aRightColumn string ) *Builder {
+	theOp := strings.TrimSpace(aOperator)
+	switch theOp {
+	case "=", OPERATOR_NOT_EQUAL, "!=", "<", "<=", ">", ">=":
+	default:
+		return sqlbldr.addError(fmt.Errorf("sqlBits: operator %q cannot be used to compare columns", aOperator))
+	}//switch
+	sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.GetQuotedQualified(aLeftColumn) + " " + theOp + " " +
+		sqlbldr.GetQuotedQualified(aRightColumn)
+	return sqlbldr
+}
+
+// AddSubQueryForColumn Sub-query gets added to the SQL string.
+func (sqlbldr *Builder) AddSubQueryForColumn( aSubQuery *Builder, aColumnName string ) *Builder {
+	aSubQuery = aSubQuery.getNamespaced()
+	saveParamOp := sqlbldr.myParamOperator
+	switch strings.TrimSpace(sqlbldr.myParamOperator) {
+	case "=":
+		sqlbldr.myParamOperator = " IN "
+	case OPERATOR_NOT_EQUAL:
+		sqlbldr.myParamOperator = " NOT IN "
+	}//switch
+	sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.GetQuoted(aColumnName) +
+		sqlbldr.myParamOperator + "(" + aSubQuery.mySql + ")"
+	sqlbldr.myParamOperator = saveParamOp
+	//also merge in any params from the sub-query
+	sqlbldr.copyParamsFrom(aSubQuery)
+	return sqlbldr
+}
+
+// AddCorrelatedSubQueryForColumn Same as AddSubQueryForColumn() except that it
+// is meant for sub-queries referring to the outer query by its table alias,
+// e.g. `"u"."id" NOT IN (SELECT o.user_id FROM orders o WHERE o.region = u.region)`.
+// The column is qualified with aTableAlias so it stays unambiguous, and since such
+// sub-queries are often built from the same pieces as the outer query, any of
+// its params colliding with ours are renamed rather than overwriting ours.
+func (sqlbldr *Builder) AddCorrelatedSubQueryForColumn( aSubQuery *Builder, aColumnName string,
+	aTableAlias string,
+) *Builder {
+	theColumn := sqlbldr.GetQuoted(aColumnName)
+	if aTableAlias != "" {
+		theColumn = sqlbldr.GetQuoted(aTableAlias) + "." + theColumn
+	}
+	theOp := sqlbldr.myParamOperator
+	switch strings.TrimSpace(sqlbldr.myParamOperator) {
+	case "=":
+		theOp = " IN "
+	case OPERATOR_NOT_EQUAL:
+		theOp = " NOT IN "
+	}//switch
+	theSubSql := sqlbldr.mergeParamsFrom(aSubQuery)
+	sqlbldr.mySql += sqlbldr.myParamPrefix + theColumn + theOp + "(" + theSubSql + ")"
+	return sqlbldr
+}
+
+// AddSubQueryComparison Adds a quantified comparison of the column against the
+// results of the sub-query, e.g. `"col" > ALL (SELECT ...)`, where aQuantifier is
+// one of "ANY", "ALL", or "SOME". The sub-query's params are merged in as with
+// AddSubQueryForColumn(). SQLite does not support quantified comparisons, so an
+// error is recorded instead (see GetErrors()). Honors the ParamPrefix property.
+func (sqlbldr *Builder) AddSubQueryComparison( aColumnName string, aOperator string,
+	aQuantifier string, aSubQuery *Builder,
+) *Builder {
+	aSubQuery = aSubQuery.getNamespaced()
+	theOp := strings.TrimSpace(aOperator)
+	switch theOp {
+	case "=", OPERATOR_NOT_EQUAL, "!=", "<", "<=", ">", ">=":
+	default:
+		return sqlbldr.addError(fmt.Errorf("sqlBits: operator %q cannot be used with a quantified sub-query", aOperator))
+	}//switch
+	theQuantifier := strings.ToUpper(strings.TrimSpace(aQuantifier))
+	switch theQuantifier {
+	case "ANY", "ALL", "SOME":
+	default:
+		return sqlbldr.addError(fmt.Errorf("sqlBits: unknown sub-query quantifier %q", aQuantifier))
+	}//switch
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case SQLite:
+		return sqlbldr.addError(fmt.Errorf("sqlBits: %s sub-query comparisons are not supported for driver %q",
+			theQuantifier, driverName))
+	}//switch
+	sqlbldr.mySql += sqlbldr.myParamPrefix + sqlbldr.GetQuoted(aColumnName) + " " + theOp + " " +
+		theQuantifier + " (" + aSubQuery.mySql + ")"
+	sqlbldr.copyParamsFrom(aSubQuery)
+	return sqlbldr
+}
+
+// WithParamNamespace Prefixes all of our params with aPrefix, e.g. "f1_" turns
+// ":id" into ":f1_id", once they are merged into another builder by way of
+// ApplyFilter() and the like, so that several filters using the same param names
+// may be applied to one query without clashing. The prefix is given to both the
+// SQL param tokens and the param keys alike; params are set and read using their
+// plain names on this builder itself. Pass "" to stop prefixing.
+func (sqlbldr *Builder) WithParamNamespace( aPrefix string ) *Builder {
+	sqlbldr.myParamNamespace = aPrefix
+	return sqlbldr
+}
+
+// getNamespaced Returns ourselves, or if a param namespace was set, a copy of us
+// whose SQL param tokens and param keys all carry the namespace prefix.
+func (sqlbldr *Builder) getNamespaced() *Builder {
+	if sqlbldr == nil || sqlbldr.myParamNamespace == "" {
+		return sqlbldr
+	}
+	theNamespace := sqlbldr.myParamNamespace
+	theNewBuilder := *sqlbldr
+	theNewBuilder.myParamNamespace = ""
+	theNewBuilder.myParams = make(map[string]*string, len(sqlbldr.myParams))
+	for k, v := range sqlbldr.myParams {
+		theNewBuilder.myParams[theNamespace+k] = v
+	}
+	theNewBuilder.mySetParams = make(map[string]*[]string, len(sqlbldr.mySetParams))
+	for k, v := range sqlbldr.mySetParams {
+		theNewBuilder.mySetParams[theNamespace+k] = v
+	}
+	theNewBuilder.myTypedParams = make(map[string]interface{}, len(sqlbldr.myTypedParams))
+	for k, v := range sqlbldr.myTypedParams {
+		theNewBuilder.myTypedParams[theNamespace+k] = v
+	}
+	var theSql strings.Builder
+	theLastPos := 0
+	for _, theToken := range getParamTokens(sqlbldr.mySql) {
+		if _, ok := sqlbldr.myParams[theToken.key]; ok {
+			theSql.WriteString(sqlbldr.mySql[theLastPos:theToken.start])
+			theSql.WriteString(":" + theNamespace + theToken.key)
+			theLastPos = theToken.end
+		}
+	}
+	theSql.WriteString(sqlbldr.mySql[theLastPos:])
+	theNewBuilder.mySql = theSql.String()
+	return &theNewBuilder
+}
+
+// copyParamsFrom Copy all params from another builder into ours as-is.
+func (sqlbldr *Builder) copyParamsFrom( aOther *Builder ) {
+	for k, v := range aOther.myParams {
+		sqlbldr.myParams[k] = v
+	}
+	for k, v := range aOther.mySetParams {
+		sqlbldr.mySetParams[k] = v
+	}
+	for k, v := range aOther.myTypedParams {
+		sqlbldr.myTypedParams[k] = v
+	}
+}
+
+// ApplyFilter Apply an externally defined set of WHERE field clauses and param
+// values to our SQL (excludes the "WHERE" keyword).
+func (sqlbldr *Builder) ApplyFilter( aFilter *Builder ) *Builder {
+	return sqlbldr.applyFilterWrapped(aFilter, "", "")
+}
+
+// applyFilterWrapped Appends the filter's clauses between aOpen and aClose,
+// prefixed with the ParamPrefix "glue", and merges in the filter's params.
+func (sqlbldr *Builder) applyFilterWrapped( aFilter *Builder, aOpen string, aClose string ) *Builder {
+	aFilter = aFilter.getNamespaced()
+	if aFilter != nil {
+		if aFilter.mySql != "" {
+			sqlbldr.mySql += sqlbldr.myParamPrefix + aOpen + aFilter.mySql + aClose
+		}
+		//also merge in any params from the sub-query
+		sqlbldr.copyParamsFrom(aFilter)
+	}
+	return sqlbldr
+}
+
+// replaceParamToken Replace all ":aOldKey" param tokens in aSql with ":aNewKey",
+// leaving longer param names that merely start with aOldKey, quoted text, and
+// PostgreSQL "::type" casts untouched.
+func replaceParamToken( aSql string, aOldKey string, aNewKey string ) string {
+	var theResult strings.Builder
+	theLastPos := 0
+	for _, theToken := range getParamTokens(aSql) {
+		if theToken.key == aOldKey {
+			theResult.WriteString(aSql[theLastPos:theToken.start])
+			theResult.WriteString(":" + aNewKey)
+			theLastPos = theToken.end
+		}
+	}
+	theResult.WriteString(aSql[theLastPos:])
+	return theResult.String()
+}
+
+// mergeParamsFrom Merge in the params from another builder, renaming any of its
+// param keys that would collide with ours. Returns the other builder's SQL with
+// its param tokens renamed to match.
+func (sqlbldr *Builder) mergeParamsFrom( aOther *Builder ) string {
+	aOther = aOther.getNamespaced()
+	theSql := aOther.mySql
+	theKeys := make([]string, 0, len(aOther.myParams))
+	for k := range aOther.myParams {
+		theKeys = append(theKeys, k)
+	}
+	sort.Strings(theKeys)
+	for _, k := range theKeys {
+		theNewKey := k
+		if _, bCollides := sqlbldr.myParams[k]; bCollides {
+			i := 1
+			for bCollides {
+				i += 1
+				theNewKey = k + strconv.Itoa(i)
+				_, bCollides = sqlbldr.myParams[theNewKey]
+				if _, bOtherHas := aOther.myParams[theNewKey]; bOtherHas {
+					bCollides = true
+				}
+			}
+			theSql = replaceParamToken(theSql, k, theNewKey)
+		}
+		sqlbldr.myParams[theNewKey] = aOther.myParams[k]
+		if valSet, ok := aOther.mySetParams[k]; ok {
+			sqlbldr.mySetParams[theNewKey] = valSet
+		}
+		if v, ok := aOther.myTypedParams[k]; ok {
+			sqlbldr.myTypedParams[theNewKey] = v
+		}
+	}
+	return theSql
+}
+
+// combineWith Append another builder's query using the set operation keyword.
+func (sqlbldr *Builder) combineWith( aKeyword string, aOther *Builder ) *Builder {
+	if aOther != nil && aOther.mySql != "" {
+		sqlbldr.mySql += " " + aKeyword + " " + sqlbldr.mergeParamsFrom(aOther)
+	}
+	return sqlbldr
+}
+
+// Union Combine our query with another using "UNION", merging its params and
+// renaming any that would collide with ours. ORDER BY/LIMIT may be added afterward
+// to apply to the combined result, so neither query should contain its own.
+func (sqlbldr *Builder) Union( aOther *Builder ) *Builder {
+	return sqlbldr.combineWith("UNION", aOther)
+}
+
+// UnionAll Combine our query with another using "UNION ALL", merging its params and
+// renaming any that would collide with ours. ORDER BY/LIMIT may be added afterward
+// to apply to the combined result, so neither query should contain its own.
+func (sqlbldr *Builder) UnionAll( aOther *Builder ) *Builder {
+	return sqlbldr.combineWith("UNION ALL", aOther)
+}
+
+// WithRecursiveCTE Prepends a recursive common table expression to our SQL,
+// "WITH RECURSIVE name AS (anchor UNION ALL recursive) ...", for walking
+// hierarchies such as trees; the recursive part refers to the CTE by aName.
+// Params of both parts are merged in, renaming any that collide with ours.
+// Call it again to define several CTEs, each placed ahead of those defined
+// before it. MySQL 8+, PostgreSQL, and SQLite all share this syntax.
+func (sqlbldr *Builder) WithRecursiveCTE( aName string, aAnchor *Builder, aRecursive *Builder ) *Builder {
+	if aAnchor == nil || aRecursive == nil {
+		return sqlbldr.addError(fmt.Errorf("sqlBits: recursive CTE %q requires both an anchor and a recursive query", aName))
+	}
+	theCte := sqlbldr.GetQuoted(aName) + " AS (" + strings.TrimSpace(sqlbldr.mergeParamsFrom(aAnchor)) +
+		" UNION ALL " + strings.TrimSpace(sqlbldr.mergeParamsFrom(aRecursive)) + ")"
+	theSql := strings.TrimLeft(sqlbldr.mySql, " ")
+	theUpperSql := strings.ToUpper(theSql)
+	switch {
+	case strings.HasPrefix(theUpperSql, "WITH RECURSIVE "):
+		sqlbldr.mySql = theSql[:len("WITH RECURSIVE ")] + theCte + ", " + theSql[len("WITH RECURSIVE "):]
+	case strings.HasPrefix(theUpperSql, "WITH "):
+		sqlbldr.mySql = "WITH RECURSIVE " + theCte + ", " + theSql[len("WITH "):]
+	default:
+		sqlbldr.mySql = "WITH RECURSIVE " + theCte + " " + theSql
+	}//switch
+	return sqlbldr
+}
+
+// ApplyFilterGroup Apply an externally defined set of WHERE field clauses and param
+// values to our SQL wrapped in parentheses (excludes the "WHERE" keyword).
+func (sqlbldr *Builder) ApplyFilterGroup( aFilter *Builder ) *Builder {
+	return sqlbldr.applyFilterWrapped(aFilter, "(", ")")
+}
+
+// ApplyFilterOr Apply an externally defined set of WHERE field clauses and param
+// values to our SQL wrapped in parentheses and OR-joined to the clauses already
+// added, which get wrapped in parentheses as well so that their precedence is kept
+// (excludes the "WHERE" keyword), e.g. "WHERE (a=:a AND b=:b) OR (c=:c)".
+// Our clauses are those of the WHERE clause started with StartWhereClause(), even
+// once EndWhereClause() was called, or all of a StartFilter() filter; any SQL
+// following a closed WHERE clause is kept as is. Lacking either, an error is
+// added as there is nothing to safely OR-join with.
+// Should there be no clauses yet, e.g. right after StartWhereClause(), or only the
+// StartFilter() placeholder which would match every row, the filter's clauses
+// simply become ours. The ParamPrefix property is left unchanged.
+func (sqlbldr *Builder) ApplyFilterOr( aFilter *Builder ) *Builder {
+	aFilter = aFilter.getNamespaced()
+	if aFilter != nil {
+		if aFilter.mySql != "" {
+			theClausesStart, theClausesEnd := sqlbldr.getWhereClauseSpan()
+			if theClausesStart < 0 {
+				return sqlbldr.addError(fmt.Errorf("sqlBits: ApplyFilterOr requires a " +
+					"WHERE clause begun with StartWhereClause() or a StartFilter() filter"))
+			}
+			theClauses := strings.TrimSpace(sqlbldr.mySql[theClausesStart:theClausesEnd])
+			theKeyword := ""
+			if !sqlbldr.bIsFilter && len(theClauses) > len("WHERE") &&
+				strings.EqualFold(theClauses[:len("WHERE")], "WHERE") && !isWordChar(theClauses[len("WHERE")]) {
+				theKeyword = " WHERE "
+				theClauses = strings.TrimSpace(theClauses[len("WHERE"):])
+			}
+			var theNewClauses string
+			switch theClauses {
+			case "":
+				theNewClauses = sqlbldr.myParamPrefix + "(" + aFilter.mySql + ")"
+			case sqlbldr.getBoolLiteral(true):
+				// the StartFilter() placeholder OR'd would match every row, replace it
+				theNewClauses = theKeyword + "(" + aFilter.mySql + ")"
+			default:
+				if theKeyword == "" {
+					theKeyword = " "
+				}
+				theNewClauses = theKeyword + "(" + theClauses + ") OR (" + aFilter.mySql + ")"
+			}//switch
+			sqlbldr.mySql = sqlbldr.mySql[:theClausesStart] + theNewClauses + sqlbldr.mySql[theClausesEnd:]
+			if sqlbldr.myWhereSql != nil {
+				// keep a closed WHERE clause, and the filter's params, removable by ClearWhere()
+				sqlbldr.myWhereSql = &theNewClauses
+				for k := range aFilter.myParams {
+					if !sqlbldr.myPreWhereParams[k] {
+						sqlbldr.myWhereParamKeys = append(sqlbldr.myWhereParamKeys, k)
+					}
+				}
+			}
+		}
+		//also merge in any params from the sub-query
+		sqlbldr.copyParamsFrom(aFilter)
+	}
+	return sqlbldr
+}
+
+// getWhereClauseSpan Returns where our WHERE clause, keyword included, starts and
+// ends within our SQL: the one begun with StartWhereClause(), whether or not it has
+// been closed with EndWhereClause(), or all of a StartFilter() filter. Returns -1
+// for both should there be no such clause.
+func (sqlbldr *Builder) getWhereClauseSpan() (int, int) {
+	switch {
+	case sqlbldr.myWhereSql != nil:
+		if *sqlbldr.myWhereSql != "" {
+			if idx := strings.LastIndex(sqlbldr.mySql, *sqlbldr.myWhereSql); idx >= 0 {
+				return idx, idx + len(*sqlbldr.myWhereSql)
+			}
+		}
+	case sqlbldr.myWhereStart >= 0 && sqlbldr.myWhereStart <= len(sqlbldr.mySql):
+		return sqlbldr.myWhereStart, len(sqlbldr.mySql)
+	case sqlbldr.bIsFilter:
+		return 0, len(sqlbldr.mySql)
+	}//switch
+	return -1, -1
+}
+
+// ApplyFilterNot Apply an externally defined set of WHERE field clauses and param
+// values to our SQL negated as a whole, "NOT (...)" (excludes the "WHERE" keyword).
+func (sqlbldr *Builder) ApplyFilterNot( aFilter *Builder ) *Builder {
+	return sqlbldr.applyFilterWrapped(aFilter, "NOT (", ")")
+}
+
+// AddUpdateFrom Joins another table into an UPDATE statement so rows may be updated
+// based on it, using the dialect specific syntax; PostgreSQL and SQLite append
+// "FROM table WHERE (on clause)" while MySQL inserts "JOIN table ON (on clause)"
+// ahead of the SET clause. Call this once the SET clause is complete; it starts the
+// WHERE clause, so follow it with AddParam kinds of methods to further restrict the
+// update. Params of aOnClause are merged in, renaming any that collide with ours.
+func (sqlbldr *Builder) AddUpdateFrom( aJoinTable string, aOnClause *Builder ) *Builder {
+	theOnSql := "true"
+	if aOnClause != nil && aOnClause.mySql != "" {
+		theOnSql = sqlbldr.mergeParamsFrom(aOnClause)
+	}
+	theJoinTable := sqlbldr.GetQuotedQualified(aJoinTable)
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case MySQL:
+		idx := indexOfTopLevelKeyword(sqlbldr.mySql, reKeywordSet)
+		if idx < 0 {
+			return sqlbldr.addError(fmt.Errorf("sqlBits: AddUpdateFrom(%q) requires a SET clause", aJoinTable))
+		}
+		sqlbldr.mySql = sqlbldr.mySql[:idx] + "JOIN " + theJoinTable + " ON (" + theOnSql + ") " +
+			sqlbldr.mySql[idx:]
+		return sqlbldr.StartWhereClause()
+	default:
+		sqlbldr.mySql += " FROM " + theJoinTable
+		sqlbldr.StartWhereClause()
+		sqlbldr.mySql += sqlbldr.myParamPrefix + "(" + theOnSql + ")"
+		return sqlbldr.And()
+	}//switch
+}
+
+// AddDeleteUsing Joins another table into a "DELETE FROM table" statement so rows
+// may be deleted based on it, using the dialect specific syntax; PostgreSQL appends
+// "USING table WHERE (on clause)", MySQL inserts the target ahead of FROM and
+// appends a join, "DELETE target FROM target JOIN table ON (on clause)", and
+// SQLite (which lacks multi-table deletes) appends
+// "WHERE EXISTS (SELECT 1 FROM table WHERE on clause)".
+// It starts the WHERE clause, so follow it with AddParam kinds of methods to further
+// restrict the delete. Params of aOnClause are merged in, renaming any that collide.
+func (sqlbldr *Builder) AddDeleteUsing( aJoinTable string, aOnClause *Builder ) *Builder {
+	theOnSql := "true"
+	if aOnClause != nil && aOnClause.mySql != "" {
+		theOnSql = sqlbldr.mergeParamsFrom(aOnClause)
+	}
+	theJoinTable := sqlbldr.GetQuotedQualified(aJoinTable)
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case MySQL:
+		idx := indexOfTopLevelKeyword(sqlbldr.mySql, reKeywordFrom)
+		var theTableRef []string
+		if idx >= 0 {
+			theTableRef = strings.Fields(sqlbldr.mySql[idx:])
+		}
+		if len(theTableRef) < 2 {
+			return sqlbldr.addError(fmt.Errorf("sqlBits: AddDeleteUsing(%q) requires a DELETE FROM table", aJoinTable))
+		}
+		// delete from the aliased table name, if one was given
+		theTarget := theTableRef[1]
+		if len(theTableRef) > 3 && strings.EqualFold(theTableRef[2], "AS") {
+			theTarget = theTableRef[3]
+		}
+		// insert the target ahead of FROM, keeping any LOW_PRIORITY/QUICK/IGNORE modifiers
+		theTarget += " "
+		sqlbldr.mySql = sqlbldr.mySql[:idx] + theTarget + sqlbldr.mySql[idx:] +
+			" JOIN " + theJoinTable + " ON (" + theOnSql + ")"
+		if sqlbldr.myWhereStart >= idx {
+			sqlbldr.myWhereStart += len(theTarget)
+		}
+		return sqlbldr.StartWhereClause()
+	case PostgreSQL:
+		sqlbldr.mySql += " USING " + theJoinTable
+		sqlbldr.StartWhereClause()
+		sqlbldr.mySql += sqlbldr.myParamPrefix + "(" + theOnSql + ")"
+		return sqlbldr.And()
+	default:
+		sqlbldr.StartWhereClause()
+		sqlbldr.mySql += sqlbldr.myParamPrefix + "EXISTS (SELECT 1 FROM " + theJoinTable +
+			" WHERE " + theOnSql + ")"
+		return sqlbldr.And()
+	}//switch
+}
+
+// ApplySortList If sort list is defined and its contents are also contained
+// in the non-empty $aFieldList, then apply the sort order as neccessary.
+// @see ApplyOrderByList() which this method is an alias of.
+func (sqlbldr *Builder) ApplySortList( aSortList *OrderByList ) *Builder {
+	return sqlbldr.ApplyOrderByList(aSortList)
+}
+
+// ApplyOrderByList If order by list is defined, then apply the sort order as neccessary.
+// Being a map, the list has no order so its fields are sorted by name; use
+// ApplyOrderBySequence() when the precedence of the fields matters.
+func (sqlbldr *Builder) ApplyOrderByList( aOrderByList *OrderByList ) *Builder {
+	if aOrderByList == nil {
+		return sqlbldr
+	}
+	return sqlbldr.ApplyOrderBySequence(aOrderByList.AsSequence())
+}
+
+// getSanitizedOrderBySequence Returns the fields of the sequence the sanitizer
+// allows to be sorted, in their original order, falling back to its default sort
+// if none remain. Names of SetOrderByExpressions() expressions are sanitized too,
+// so the sanitizer must also approve them.
+func (sqlbldr *Builder) getSanitizedOrderBySequence( aOrderBy OrderBySequence ) OrderBySequence {
+	var theResult OrderBySequence
+	if len(aOrderBy) > 0 {
+		theSanitizedList := sqlbldr.mySqlSanitizer.GetSanitizedOrderByList(aOrderBy.AsList())
+		for _, theEntry := range aOrderBy {
+			if v, ok := theSanitizedList[theEntry.Field]; ok {
+				theResult = append(theResult, OrderBy{Field: theEntry.Field, Direction: v})
+			}
+		}
+	}
+	if len(theResult) == 0 {
+		theResult = sqlbldr.mySqlSanitizer.GetDefaultSort().AsSequence()
+	}
+	return theResult
+}
+
+// ApplyOrderBySequence If the sequence is defined, then apply the sort order with
+// its fields in the same order. Field names are quoted, each part of a "table.field"
+// name separately. If a sanitizer was set with SetSanitizer(), fields it does not
+// allow to be sorted are pruned, falling back to its default sort if none remain.
+// Names whitelisted with SetOrderByExpressions() are replaced by their SQL
+// expression instead. After AddDistinctOn(), a sequence not led by its columns in
+// the same order records an error instead of being applied.
+func (sqlbldr *Builder) ApplyOrderBySequence( aOrderBy OrderBySequence ) *Builder {
+	if len(aOrderBy) > 0 && sqlbldr.mySqlSanitizer != nil {
+		aOrderBy = sqlbldr.getSanitizedOrderBySequence(aOrderBy)
+	}
+	if len(sqlbldr.myDistinctOn) > 0 && len(aOrderBy) > 0 {
+		// DISTINCT ON columns must lead the ORDER BY, in order, else PostgreSQL rejects the query
+		for i, theColumn := range sqlbldr.myDistinctOn {
+			if i >= len(aOrderBy) || aOrderBy[i].Field != theColumn {
+				return sqlbldr.addError(fmt.Errorf("sqlBits: DISTINCT ON column %q must be ORDER BY field %d",
+					theColumn, i+1))
+			}
+		}
+	}
+	if len(aOrderBy) > 0 && sqlbldr.myDbModel != nil {
+		theSortKeyword := "ORDER BY"
 		/* in case we find diff keywords later...
 		driverName := sqlbldr.myDbModel.GetDbMeta().Name
 		switch driverName {
@@ -515,23 +2185,161 @@ func (sqlbldr *Builder) ApplyOrderByList( aOrderByList *OrderByList ) *Builder {
 		*/
 		sqlbldr.Add(theSortKeyword)
 
-		theOrderByList := make([]string, len(*aOrderByList))
-		idx := 0
-		for k, v := range *aOrderByList {
-			theEntry := k + " "
-			if strings.ToUpper(strings.TrimSpace(v)) == ORDER_BY_DESCENDING {
-				theEntry += ORDER_BY_DESCENDING
+		theOrderByList := make([]string, len(aOrderBy))
+		for i, theEntry := range aOrderBy {
+			if theExpr, ok := sqlbldr.myOrderByExprs[theEntry.Field]; ok {
+				theOrderByList[i] = theExpr + " " + sqlbldr.getOrderByDirection(theEntry.Direction)
 			} else {
-				theEntry += ORDER_BY_ASCENDING
+				//quote the field in case it is a keyword like "order" or mixed case
+				theOrderByList[i] = sqlbldr.GetQuotedQualified(theEntry.Field) + " " +
+					sqlbldr.getOrderByDirection(theEntry.Direction)
 			}
-			theOrderByList[idx] = theEntry
-			idx += 1
 		}
 		sqlbldr.Add(strings.Join(theOrderByList, ","))
 	}
 	return sqlbldr
 }
 
+// AddDistinctOn Makes the SELECT return only the first row of each set of rows
+// sharing the same values for the columns, "SELECT DISTINCT ON (a, b) ...", where
+// which row comes first depends on the ORDER BY. PostgreSQL requires the columns
+// to lead the ORDER BY, in the same order, so ApplyOrderBySequence() records an
+// error (see GetErrors()) rather than apply a sequence that does not start with them.
+// Only PostgreSQL supports DISTINCT ON; other dialects record an error instead.
+func (sqlbldr *Builder) AddDistinctOn( aColumns ...string ) *Builder {
+	driverName := sqlbldr.dbMeta().Name
+	if driverName != PostgreSQL {
+		return sqlbldr.addError(fmt.Errorf("sqlBits: DISTINCT ON is not supported for driver %q", driverName))
+	}
+	if len(aColumns) == 0 {
+		return sqlbldr.addError(fmt.Errorf("sqlBits: DISTINCT ON requires at least one column"))
+	}
+	idx := indexOfTopLevelKeyword(sqlbldr.mySql, reKeywordSelect)
+	if idx < 0 {
+		return sqlbldr.addError(fmt.Errorf("sqlBits: DISTINCT ON requires a SELECT statement"))
+	}
+	theColumns := make([]string, len(aColumns))
+	for i, theColumn := range aColumns {
+		theColumns[i] = sqlbldr.GetQuotedQualified(theColumn)
+	}
+	idx += len("SELECT")
+	sqlbldr.mySql = sqlbldr.mySql[:idx] + " DISTINCT ON (" + strings.Join(theColumns, ", ") + ")" +
+		sqlbldr.mySql[idx:]
+	sqlbldr.myDistinctOn = append(sqlbldr.myDistinctOn, aColumns...)
+	return sqlbldr
+}
+
+// SetOrderByExpressions Whitelist SQL expressions to sort by, keyed by the name
+// used for them in an OrderByList; e.g. {"name_len": "LENGTH(name)"} lets
+// OrderByList{"name_len": "DESC"} apply "ORDER BY LENGTH(name) DESC". Being
+// emitted as-is, expressions must never contain user input. If a sanitizer is set,
+// it must also approve the names, e.g. by listing them as sortable; otherwise
+// they are dropped like any other unknown field. This setting is not affected by
+// Reset().
+func (sqlbldr *Builder) SetOrderByExpressions( aExprs map[string]string ) *Builder {
+	sqlbldr.myOrderByExprs = aExprs
+	return sqlbldr
+}
+
+// getOrderByDirection Returns the sort direction SQL for an OrderByList value,
+// e.g. "DESC NULLS LAST". The NULLS FIRST/LAST option is dropped for MySQL which
+// does not support it.
+func (sqlbldr *Builder) getOrderByDirection( aValue string ) string {
+	theDirection := ORDER_BY_ASCENDING
+	theWords := strings.Fields(strings.ToUpper(aValue))
+	if len(theWords) > 0 && theWords[0] == ORDER_BY_DESCENDING {
+		theDirection = ORDER_BY_DESCENDING
+	}
+	if n := len(theWords); n >= 2 && sqlbldr.dbMeta().Name != MySQL {
+		switch theNulls := strings.Join(theWords[n-2:], " "); theNulls {
+		case ORDER_BY_NULLS_FIRST, ORDER_BY_NULLS_LAST:
+			theDirection += " " + theNulls
+		}//switch
+	}
+	return theDirection
+}
+
+// ApplySeekPagination Keyset pagination avoids the cost of large OFFSETs by
+// continuing after the sort key values of the last row of the previous page, e.g.
+// `("a", "b") > (:seek_a, :seek_b) ORDER BY "a" ASC, "b" ASC LIMIT 20`.
+// The sort columns are compared in the order of aOrderBy, most significant first,
+// which must match the ORDER BY of the previous page. Descending columns flip the
+// comparison; mixed directions expand into the equivalent
+// `("a" > :seek_a) OR ("a" = :seek_a AND "b" < :seek_b)` form.
+// No comparison is added if aAfterValues is empty, i.e. for the first page.
+// The comparison honors the ParamPrefix property.
+func (sqlbldr *Builder) ApplySeekPagination( aOrderBy OrderBySequence, aAfterValues map[string]string,
+	aLimit int,
+) *Builder {
+	if len(aOrderBy) > 0 && sqlbldr.mySqlSanitizer != nil {
+		aOrderBy = sqlbldr.getSanitizedOrderBySequence(aOrderBy)
+	}
+	if len(aOrderBy) == 0 {
+		sqlbldr.addError(fmt.Errorf("sqlBits: ApplySeekPagination() requires an order by list"))
+		return sqlbldr
+	}
+	theColumns := make([]string, len(aOrderBy))
+	theQuoted := make([]string, len(aOrderBy))
+	theDescs := make([]bool, len(aOrderBy))
+	theOrderByList := make([]string, len(aOrderBy))
+	for i, theEntry := range aOrderBy {
+		theColumns[i] = theEntry.Field
+		theQuoted[i] = sqlbldr.GetQuotedQualified(theEntry.Field)
+		theDirection := sqlbldr.getOrderByDirection(theEntry.Direction)
+		theDescs[i] = strings.HasPrefix(theDirection, ORDER_BY_DESCENDING)
+		theOrderByList[i] = theQuoted[i] + " " + theDirection
+	}
+	if len(aAfterValues) > 0 {
+		theParams := make([]string, len(theColumns))
+		for i, theColumn := range theColumns {
+			theValue, ok := aAfterValues[theColumn]
+			if !ok {
+				sqlbldr.addError(fmt.Errorf("sqlBits: missing seek value for order by column %q", theColumn))
+				return sqlbldr
+			}
+			theParamKey := sqlbldr.GetUniqueParamKey("seek_" + strings.Replace(theColumn, ".", "_", -1))
+			sqlbldr.SetParam(theParamKey, theValue)
+			theParams[i] = ":" + theParamKey
+		}
+		sqlbldr.mySql += sqlbldr.myParamPrefix + getSeekComparison(theQuoted, theParams, theDescs)
+	}
+	sqlbldr.Add("ORDER BY").Add(strings.Join(theOrderByList, ", "))
+	if aLimit > 0 {
+		sqlbldr.Add("LIMIT").Add(strconv.Itoa(aLimit))
+	}
+	return sqlbldr
+}
+
+// getSeekComparison Returns the SQL comparing the columns to the params such that
+// only rows sorting after the params match.
+func getSeekComparison( aColumns []string, aParams []string, aDescs []bool ) string {
+	getOp := func( bDesc bool ) string {
+		if bDesc {
+			return " < "
+		}
+		return " > "
+	}
+	bMixed := false
+	for _, bDesc := range aDescs {
+		bMixed = bMixed || bDesc != aDescs[0]
+	}
+	if len(aColumns) == 1 {
+		return aColumns[0] + getOp(aDescs[0]) + aParams[0]
+	} else if !bMixed {
+		return "(" + strings.Join(aColumns, ", ") + ")" + getOp(aDescs[0]) +
+			"(" + strings.Join(aParams, ", ") + ")"
+	}
+	theTerms := make([]string, len(aColumns))
+	for i := range aColumns {
+		theTerm := ""
+		for j := 0; j < i; j += 1 {
+			theTerm += aColumns[j] + " = " + aParams[j] + " AND "
+		}
+		theTerms[i] = "(" + theTerm + aColumns[i] + getOp(aDescs[i]) + aParams[i] + ")"
+	}
+	return "(" + strings.Join(theTerms, " OR ") + ")"
+}
+
 // ReplaceSelectFieldsWith Replace the currently formed SELECT fields with the param.
 // If you have nested queries, you will need to use the FIELD_LIST_HINT_* consts in
 // the SQL like so:
@@ -542,40 +2350,516 @@ func (sqlbldr *Builder) ReplaceSelectFieldsWith( aSelectFields *[]string ) *Buil
 		//nested queries can mess us up, so check for hints first
 		if strings.Index(sqlbldr.mySql, FIELD_LIST_HINT_START) > 0 &&
 			strings.Index(sqlbldr.mySql, FIELD_LIST_HINT_END) > 0 {
-			re = regexp.MustCompilePOSIX("(?i)SELECT /* FIELDLIST */ .+? /* /FIELDLIST */ FROM")
+			re = regexp.MustCompile(`(?is)SELECT\s+` + regexp.QuoteMeta(FIELD_LIST_HINT_START) +
+				`.+?` + regexp.QuoteMeta(FIELD_LIST_HINT_END) + `\s+FROM\s`)
 		} else {
 			//we want a "non-greedy" match so that it stops at the first "FROM" it finds: ".+?"
-			re = regexp.MustCompilePOSIX("(?i)SELECT .+? FROM")
+			re = regexp.MustCompile(`(?is)SELECT\s+.+?\s+FROM\s`)
+		}
+		//only the outermost (first) SELECT field list gets replaced
+		if loc := re.FindStringIndex(sqlbldr.mySql); loc != nil {
+			sqlbldr.mySql = sqlbldr.mySql[:loc[0]] + "SELECT " + strings.Join(*aSelectFields, ", ") +
+				" FROM " + sqlbldr.mySql[loc[1]:]
 		}
-		sqlbldr.mySql = re.ReplaceAllString(sqlbldr.mySql, strings.Join(*aSelectFields, ", "))
 	}
 	return sqlbldr
 }
 
-// GetSQLStatement Return our currently built SQL statement.
+// isWordChar Returns TRUE if the byte may be part of an identifier or keyword.
+func isWordChar( c byte ) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// getKeywordRegexp Returns the compiled keyword pattern (e.g. `ORDER\s+BY`) for use
+// with indexOfTopLevelKeyword(), matched case-insensitively as a whole word.
+func getKeywordRegexp( aKeywordPattern string ) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)^` + aKeywordPattern + `\b`)
+}
+
+// Keywords looked for with indexOfTopLevelKeyword().
+var (
+	reKeywordSelect         = getKeywordRegexp(`SELECT`)
+	reKeywordSelectDistinct = getKeywordRegexp(`SELECT\s+DISTINCT`)
+	reKeywordFrom           = getKeywordRegexp(`FROM`)
+	reKeywordSet            = getKeywordRegexp(`SET`)
+	reKeywordGroupBy        = getKeywordRegexp(`GROUP\s+BY`)
+	reKeywordUnion          = getKeywordRegexp(`UNION`)
+	reKeywordOrderBy        = getKeywordRegexp(`ORDER\s+BY`)
+	reKeywordLimit          = getKeywordRegexp(`LIMIT`)
+	reKeywordOffset         = getKeywordRegexp(`OFFSET`)
+)
+
+// indexOfTopLevelKeyword Returns the index of the first occurrence of the keyword
+// (see getKeywordRegexp()) in aSql which is not inside quotes or parentheses nor
+// part of a param name such as ":limit"; -1 if not found.
+func indexOfTopLevelKeyword( aSql string, aKeyword *regexp.Regexp ) int {
+	theDepth := 0
+	var theQuote byte
+	for i := 0; i < len(aSql); i++ {
+		c := aSql[i]
+		if theQuote != 0 {
+			if c == theQuote {
+				theQuote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			theQuote = c
+		case '(':
+			theDepth += 1
+		case ')':
+			theDepth -= 1
+		default:
+			if theDepth == 0 && isWordChar(c) &&
+				(i == 0 || !isWordChar(aSql[i-1]) && aSql[i-1] != ':') &&
+				aKeyword.MatchString(aSql[i:]) {
+				return i
+			}
+		}//switch
+	}
+	return -1
+}
+
+// getSqlWithoutOrderByOrLimit Returns our SQL with any top level ORDER BY,
+// LIMIT, and OFFSET clauses removed; e.g. for use in a query total.
+func (sqlbldr *Builder) getSqlWithoutOrderByOrLimit() string {
+	theSql := sqlbldr.mySql
+	for _, theKeyword := range []*regexp.Regexp{reKeywordOrderBy, reKeywordLimit, reKeywordOffset} {
+		if idx := indexOfTopLevelKeyword(theSql, theKeyword); idx >= 0 {
+			theSql = theSql[:idx]
+		}
+	}
+	return strings.TrimRight(theSql, " ")
+}
+
+// AsExplain Returns a clone of our Builder whose SQL is prefixed with the
+// dialect specific EXPLAIN so the query plan is returned instead of data.
+// If bAnalyze is set, the query is actually run to report real timings using
+// "EXPLAIN ANALYZE"; SQLite only supports "EXPLAIN QUERY PLAN" and ignores it.
+func (sqlbldr *Builder) AsExplain( bAnalyze bool ) *Builder {
+	theExplain := "EXPLAIN "
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case SQLite:
+		theExplain = "EXPLAIN QUERY PLAN "
+	default:
+		if bAnalyze {
+			theExplain = "EXPLAIN ANALYZE "
+		}
+	}//switch
+	theNewBuilder := *sqlbldr
+	theNewBuilder.mySql = theExplain + strings.TrimLeft(sqlbldr.mySql, " ")
+	return &theNewBuilder
+}
+
+// GetSQLStatement Return our currently built SQL statement with its named params
+// using the driver's sigil; see getNamedSql().
 func (sqlbldr *Builder) GetSQLStatement() string {
-	return sqlbldr.mySql
+	return sqlbldr.getNamedSql()
+}
+
+// GetNormalizedSQL Return our currently built SQL statement with runs of whitespace
+// collapsed into a single space and both ends trimmed, which is handy for logging
+// and comparing statements. Quoted strings and identifiers are left as-is. Named
+// params use the driver's sigil; see getNamedSql().
+func (sqlbldr *Builder) GetNormalizedSQL() string {
+	theNamedSql := sqlbldr.getNamedSql()
+	var theSql strings.Builder
+	var theQuote byte
+	bInSpace := false
+	for i := 0; i < len(theNamedSql); i++ {
+		c := theNamedSql[i]
+		if theQuote == 0 {
+			switch c {
+			case ' ', '\t', '\n', '\r', '\f', '\v':
+				bInSpace = true
+				continue
+			case '\'', '"', '`':
+				theQuote = c
+			}//switch
+		} else if c == theQuote {
+			theQuote = 0
+		}
+		if bInSpace && theSql.Len() > 0 {
+			theSql.WriteByte(' ')
+		}
+		bInSpace = false
+		theSql.WriteByte(c)
+	}
+	return theSql.String()
+}
+
+// DebugSQL Returns our SQL statement with each param replaced by a literal of its
+// bound value, e.g. 'it''s', NULL, or 'a', 'b' for a value set, so that it may be
+// copy-pasted into a database console. FOR LOGGING/DEBUGGING ONLY; the result is
+// not safe to execute, always use SQL() with its args for that.
+func (sqlbldr *Builder) DebugSQL() string {
+	var theResult strings.Builder
+	theLastPos := 0
+	for _, theToken := range getParamTokens(sqlbldr.mySql) {
+		v, ok := sqlbldr.myParams[theToken.key]
+		if !ok {
+			continue
+		}
+		theResult.WriteString(sqlbldr.mySql[theLastPos:theToken.start])
+		theLastPos = theToken.end
+		if valSet, isSet := sqlbldr.mySetParams[theToken.key]; isSet && valSet != nil {
+			theLiterals := make([]string, len(*valSet))
+			for i, theValue := range *valSet {
+				theLiterals[i] = debugLiteral(theValue)
+			}
+			theResult.WriteString(strings.Join(theLiterals, ", "))
+		} else if v == nil {
+			theResult.WriteString("NULL")
+		} else if theTyped, isTyped := sqlbldr.myTypedParams[theToken.key]; isTyped {
+			switch theTyped.(type) {
+			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+				theResult.WriteString(*v)
+			default:
+				theResult.WriteString(debugLiteral(*v))
+			}//switch
+		} else {
+			theResult.WriteString(debugLiteral(*v))
+		}
+	}
+	theResult.WriteString(sqlbldr.mySql[theLastPos:])
+	return theResult.String()
+}
+
+// debugLiteral Returns the value as a quoted SQL string literal for DebugSQL().
+func debugLiteral( aValue string ) string {
+	return "'" + strings.Replace(aValue, "'", "''", -1) + "'"
+}
+
+// SetValidateOnSQL Determines if SQL() should call Validate() and record any
+// error it returns (see GetErrors()). This setting is not affected by Reset().
+func (sqlbldr *Builder) SetValidateOnSQL( aValidate bool ) *Builder {
+	sqlbldr.bValidateOnSQL = aValidate
+	return sqlbldr
+}
+
+// Validate Returns an error listing any ":param" used in the SQL which does not
+// have a value bound to it, else nil. PostgreSQL "::type" casts are not params.
+func (sqlbldr *Builder) Validate() error {
+	var theMissing []string
+	theSeen := map[string]bool{}
+	for _, theToken := range getParamTokens(sqlbldr.mySql) {
+		if theSeen[theToken.key] {
+			continue
+		}
+		theSeen[theToken.key] = true
+		_, bIsParam := sqlbldr.myParams[theToken.key]
+		_, bIsSet := sqlbldr.mySetParams[theToken.key]
+		if !bIsParam && !bIsSet {
+			theMissing = append(theMissing, ":"+theToken.key)
+		}
+	}
+	if len(theMissing) > 0 {
+		return fmt.Errorf("sqlBits: SQL uses params with no bound value: %s", strings.Join(theMissing, ", "))
+	}
+	return nil
 }
 
 // SQL Return our currently built SQL statement.
+// Should the driver not support named params, they are converted to the driver's
+// positional placeholders, see getPositionalBindStyle(), with their args available
+// from SQLargs(). Panics if a group started with OpenParen() was never closed.
 func (sqlbldr *Builder) SQL() string {
-	if sqlbldr.myParams != nil && len(sqlbldr.myParams) > 0 &&
-		sqlbldr.myDbModel != nil && !sqlbldr.myDbModel.GetDbMeta().SupportsNamedParams {
-		sqlbldr.myOrdQuerySql = sqlbldr.mySql
-		i := 1
-		for k, v := range sqlbldr.myParams {
-			theOldKey := ":"+k
-			theNewKey := "$"+strconv.Itoa(i)
-			if strings.Contains(sqlbldr.myOrdQuerySql, theOldKey) && v != nil {
-				sqlbldr.myOrdQuerySql = strings.Replace(sqlbldr.myOrdQuerySql, theOldKey, theNewKey, 1)
-				sqlbldr.myOrdQueryArgs = append(sqlbldr.myOrdQueryArgs, *v)
-				i += 1
-			}
+	if len(sqlbldr.myParenPrefixes) != 0 {
+		panic("SQL() called with " + strconv.Itoa(len(sqlbldr.myParenPrefixes)) + " unclosed OpenParen() group(s)!")
+	}
+	if sqlbldr.bValidateOnSQL {
+		if err := sqlbldr.Validate(); err != nil {
+			sqlbldr.addError(err)
 		}
-		return sqlbldr.myOrdQuerySql
+	}
+	theSql := sqlbldr.mySql
+	if sqlbldr.usesPositionalParams() {
+		theSql, _ = sqlbldr.rebindParams(sqlbldr.getPositionalBindStyle())
 	} else {
+		theSql = sqlbldr.getNamedSql()
+	}
+	if sqlbldr.myLogger != nil {
+		sqlbldr.myLogger(theSql, sqlbldr.getArgs())
+	}
+	return theSql
+}
+
+// getPositionalBindStyle Returns the placeholder scheme the driver expects when it
+// does not support named params; "?" for MySQL and SQLite, "@p1" for SQL Server,
+// and "$1" for PostgreSQL and any other driver.
+func (sqlbldr *Builder) getPositionalBindStyle() BindStyle {
+	driverName := sqlbldr.dbMeta().Name
+	switch driverName {
+	case MySQL, SQLite:
+		return BindQuestion
+	case MSSQL:
+		return BindAt
+	default:
+		return BindDollar
+	}//switch
+}
+
+// getNamedSql Returns our SQL with its named params using the driver's sigil, e.g.
+// "@name" for SQL Server. Params are always built, merged, and scanned for using
+// ':' no matter the driver; this is the one place converting them to its sigil,
+// used for all SQL we hand out.
+func (sqlbldr *Builder) getNamedSql() string {
+	theSigil := sqlbldr.dbMeta().ParamSigil
+	if theSigil == ':' || len(sqlbldr.myParams) == 0 {
 		return sqlbldr.mySql
 	}
+	var theSql strings.Builder
+	theLastPos := 0
+	for _, theToken := range getParamTokens(sqlbldr.mySql) {
+		if _, ok := sqlbldr.myParams[theToken.key]; ok {
+			theSql.WriteString(sqlbldr.mySql[theLastPos:theToken.start])
+			theSql.WriteRune(theSigil)
+			theLastPos = theToken.start + 1
+		}
+	}
+	theSql.WriteString(sqlbldr.mySql[theLastPos:])
+	return theSql.String()
+}
+
+// SetLogger Sets a callback fired with the statement and its args each time SQL()
+// is called, which includes running the statement with Query()/Exec().
+// Pass nil to stop logging. This setting is not affected by Reset().
+func (sqlbldr *Builder) SetLogger( aLogger SqlLogger ) *Builder {
+	sqlbldr.myLogger = aLogger
+	return sqlbldr
+}
+
+// SetExecLogger Sets a callback fired after each Query()/Exec() with the statement,
+// its args, how long it took, and any error returned. Pass nil to stop logging.
+// This setting is not affected by Reset().
+func (sqlbldr *Builder) SetExecLogger( aLogger SqlExecLogger ) *Builder {
+	sqlbldr.myExecLogger = aLogger
+	return sqlbldr
+}
+
+// BindStyle The placeholder scheme used for params in the final SQL.
+type BindStyle int
+
+const (
+	// BindQuestion "?" placeholders, e.g. MySQL and SQLite.
+	BindQuestion BindStyle = iota
+	// BindDollar "$1" placeholders, e.g. PostgreSQL.
+	BindDollar
+	// BindNamed ":name" placeholders, as built.
+	BindNamed
+	// BindAt "@p1" placeholders, e.g. SQL Server.
+	BindAt
+)
+
+// Rebind Returns our SQL statement with its params converted to the placeholder
+// scheme of aStyle along with the args to pass in placeholder order; sql.Named()
+// args in case of BindNamed. Unlike SQL(), this does not depend on the driver,
+// which is handy when handing the query off to other libraries such as sqlx.
+func (sqlbldr *Builder) Rebind( aStyle BindStyle ) (string, []interface{}) {
+	if len(sqlbldr.myParenPrefixes) != 0 {
+		panic("Rebind() called with " + strconv.Itoa(len(sqlbldr.myParenPrefixes)) + " unclosed OpenParen() group(s)!")
+	}
+	return sqlbldr.rebindParams(aStyle)
+}
+
+// rebindParams Converts the ":name" params of our SQL into the placeholders of
+// aStyle, assigned in the order the params appear in the SQL, returning the args
+// to go along with them. A param used more than once re-uses its numbered
+// placeholder, while "?" placeholders require the arg to be repeated.
+func (sqlbldr *Builder) rebindParams( aStyle BindStyle ) (string, []interface{}) {
+	var theOrdSql strings.Builder
+	var theArgs []interface{}
+	theOrdinals := map[string]string{}
+	theLastPos := 0
+	for _, theToken := range getParamTokens(sqlbldr.mySql) {
+		v, ok := sqlbldr.myParams[theToken.key]
+		if !ok {
+			continue
+		}
+		theOrdSql.WriteString(sqlbldr.mySql[theLastPos:theToken.start])
+		theLastPos = theToken.end
+		if thePlaceholder, bSeen := theOrdinals[theToken.key]; bSeen && aStyle != BindQuestion {
+			theOrdSql.WriteString(thePlaceholder)
+			continue
+		}
+		var theArg interface{}
+		if v != nil {
+			theArg = sqlbldr.getParamArg(theToken.key, v)
+		}
+		switch aStyle {
+		case BindQuestion:
+			theOrdinals[theToken.key] = "?"
+		case BindDollar:
+			theOrdinals[theToken.key] = "$" + strconv.Itoa(len(theArgs)+1)
+		case BindAt:
+			theOrdinals[theToken.key] = "@p" + strconv.Itoa(len(theArgs)+1)
+		default:
+			theOrdinals[theToken.key] = ":" + theToken.key
+			theArg = sql.Named(theToken.key, theArg)
+		}//switch
+		theOrdSql.WriteString(theOrdinals[theToken.key])
+		theArgs = append(theArgs, theArg)
+	}
+	theOrdSql.WriteString(sqlbldr.mySql[theLastPos:])
+	return theOrdSql.String(), theArgs
+}
+
+// AssertArgsMatch Returns an error if our statement would not line up with its
+// args, a guaranteed failure once run: params used in the SQL but never set, or
+// for drivers without named params, args that do not follow the params in SQL
+// order or a count of "$n"/"@pn"/"?" placeholders differing from the number of
+// args. SQL() is not called, so nothing is logged or validated as a side effect.
+func (sqlbldr *Builder) AssertArgsMatch() error {
+	theKeys := sqlbldr.GetParamKeysInSQLOrder(false)
+	var theUnbound []string
+	for _, theKey := range theKeys {
+		if _, ok := sqlbldr.myParams[theKey]; !ok {
+			theUnbound = append(theUnbound, theKey)
+		}
+	}
+	if len(theUnbound) > 0 {
+		return fmt.Errorf("sqlBits: params %s are used in the SQL but were never set",
+			strings.Join(theUnbound, ", "))
+	}
+	if sqlbldr.myDbModel == nil || sqlbldr.dbMeta().SupportsNamedParams {
+		return nil
+	}
+	theStyle := sqlbldr.getPositionalBindStyle()
+	theSql, theArgs := sqlbldr.rebindParams(theStyle)
+	if theStyle != BindQuestion {
+		// numbered placeholders are re-used, so one arg per distinct param
+		theKeys = sqlbldr.GetParamKeysInSQLOrder(true)
+	}
+	if len(theKeys) != len(theArgs) {
+		return fmt.Errorf("sqlBits: SQL uses %d params but there are %d args",
+			len(theKeys), len(theArgs))
+	}
+	for i, theKey := range theKeys {
+		var theArg interface{}
+		if v := sqlbldr.myParams[theKey]; v != nil {
+			theArg = sqlbldr.getParamArg(theKey, v)
+		}
+		if !reflect.DeepEqual(theArg, theArgs[i]) {
+			return fmt.Errorf("sqlBits: arg %d is not bound to param %s", i+1, theKey)
+		}
+	}
+	if thePlaceholders := countPlaceholders(theSql, theStyle); thePlaceholders != len(theArgs) {
+		return fmt.Errorf("sqlBits: SQL has %d placeholders but there are %d args",
+			thePlaceholders, len(theArgs))
+	}
+	return nil
+}
+
+// countPlaceholders Returns the number of args the aStyle placeholders of aSql
+// require, outside of quotes: the highest "$n" or "@pn", or the number of "?".
+// Only placeholders of aStyle are counted so that PostgreSQL jsonb "?", "?|" and
+// "?&" operators are not mistaken for placeholders.
+func countPlaceholders( aSql string, aStyle BindStyle ) int {
+	theMaxOrdinal := 0
+	theQuestionMarks := 0
+	var theQuote byte
+	for i := 0; i < len(aSql); i++ {
+		c := aSql[i]
+		if theQuote != 0 {
+			if c == theQuote {
+				theQuote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			theQuote = c
+		case '?':
+			if aStyle == BindQuestion {
+				theQuestionMarks += 1
+			}
+		case '$', '@':
+			if (c == '$' && aStyle != BindDollar) || (c == '@' && aStyle != BindAt) {
+				continue
+			}
+			j := i + 1
+			if c == '@' {
+				if j >= len(aSql) || aSql[j] != 'p' {
+					continue
+				}
+				j += 1
+			}
+			theStart := j
+			for j < len(aSql) && aSql[j] >= '0' && aSql[j] <= '9' {
+				j += 1
+			}
+			if n, err := strconv.Atoi(aSql[theStart:j]); err == nil && n > theMaxOrdinal {
+				theMaxOrdinal = n
+			}
+			i = j - 1
+		}//switch
+	}
+	if aStyle == BindQuestion {
+		return theQuestionMarks
+	}
+	return theMaxOrdinal
+}
+
+// paramToken Location of a ":name" param token within a SQL string.
+type paramToken struct {
+	start int
+	end   int
+	key   string
+}
+
+// getParamTokens Returns the ":name" param tokens found in aSql in the order
+// they appear, skipping over quoted strings and identifiers as well as PostgreSQL
+// "::type" casts so that "created::date = :d" only yields the "d" param.
+func getParamTokens( aSql string ) []paramToken {
+	var theTokens []paramToken
+	var theQuote byte
+	for i := 0; i < len(aSql); i++ {
+		c := aSql[i]
+		if theQuote != 0 {
+			if c == theQuote {
+				theQuote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			theQuote = c
+		case ':':
+			// "::" is a PostgreSQL type cast, e.g. "created::date", not a param
+			if i+1 < len(aSql) && aSql[i+1] == ':' {
+				i += 1
+				continue
+			}
+			j := i + 1
+			for j < len(aSql) && isWordChar(aSql[j]) {
+				j += 1
+			}
+			if j > i+1 && !(aSql[i+1] >= '0' && aSql[i+1] <= '9') {
+				theTokens = append(theTokens, paramToken{start: i, end: j, key: aSql[i+1 : j]})
+				i = j - 1
+			}
+		}//switch
+	}
+	return theTokens
+}
+
+// GetParamKeysInSQLOrder Returns the keys of the ":name" params used in our SQL
+// in the order they appear, which is the order positional args must be bound in.
+// Quoted text and PostgreSQL "::type" casts are skipped. If aDistinct is set, a
+// param used more than once is only listed where it first appears.
+func (sqlbldr *Builder) GetParamKeysInSQLOrder( aDistinct bool ) []string {
+	theKeys := []string{}
+	theSeen := map[string]bool{}
+	for _, theToken := range getParamTokens(sqlbldr.mySql) {
+		if aDistinct && theSeen[theToken.key] {
+			continue
+		}
+		theSeen[theToken.key] = true
+		theKeys = append(theKeys, theToken.key)
+	}
+	return theKeys
 }
 
 // SQLparams Return our current SQL params in use.
@@ -597,16 +2881,58 @@ func (sqlbldr *Builder) SQLparamSets() map[string]*[]string {
 }
 
 // SQLargs Return SQL query arguments IFF the driver does not support named parameters.
+// The args are always determined from our current SQL and params, so they match
+// what SQL() returns even if more was added since it was last called.
 func (sqlbldr *Builder) SQLargs() []interface{} {
-	return sqlbldr.myOrdQueryArgs
+	if !sqlbldr.usesPositionalParams() {
+		return nil
+	}
+	_, theArgs := sqlbldr.rebindParams(sqlbldr.getPositionalBindStyle())
+	return theArgs
+}
+
+// usesPositionalParams Returns TRUE if our params must be converted to positional
+// placeholders because the driver does not support named parameters.
+func (sqlbldr *Builder) usesPositionalParams() bool {
+	return len(sqlbldr.myParams) > 0 && sqlbldr.myDbModel != nil && !sqlbldr.dbMeta().SupportsNamedParams
+}
+
+// getSqlAndArgs Return our SQL statement along with the arguments to pass to the
+// database/sql query methods; named args if the driver supports them, else ordinal args.
+func (sqlbldr *Builder) getSqlAndArgs() (string, []interface{}) {
+	theSql := sqlbldr.SQL()
+	return theSql, sqlbldr.getArgs()
 }
 
-// SQLnamedArgs Return SQL query arguments as named parameters.
+// getArgs Return the arguments to pass along with the statement last produced by
+// SQL(); named args if the driver supports them, else ordinal args.
+func (sqlbldr *Builder) getArgs() []interface{} {
+	if sqlbldr.myDbModel != nil && !sqlbldr.dbMeta().SupportsNamedParams {
+		return sqlbldr.SQLargs()
+	}
+	var theArgs []interface{}
+	for k, v := range sqlbldr.SQLnamedArgs() {
+		theArgs = append(theArgs, sql.Named(k, v))
+	}
+	return theArgs
+}
+
+// SQLnamedArgs Return SQL query arguments as named parameters, limited to those
+// our SQL actually uses so that no stray args reach the driver. A value set is
+// included as the SQL uses it, i.e. a single array value under the set's own key
+// for PostgreSQL's "= ANY(:key)" and the ":key_1".. members of an IN list.
+// NULL params are included as an explicit nil so every placeholder has its arg.
 func (sqlbldr *Builder) SQLnamedArgs() map[string]interface{} {
 	theResults := map[string]interface{}{}
-	for k, v := range sqlbldr.myParams {
-		if v != nil {
-			theResults[k] = *v
+	for _, k := range sqlbldr.GetParamKeysInSQLOrder(true) {
+		if v, ok := sqlbldr.myParams[k]; ok {
+			if v != nil {
+				theResults[k] = sqlbldr.getParamArg(k, v)
+			} else {
+				theResults[k] = nil
+			}
+		} else if valSet := sqlbldr.mySetParams[k]; valSet != nil {
+			theResults[k] = getArrayLiteral(*valSet)
 		}
 	}
 	return theResults
@@ -0,0 +1,1459 @@
+package sqlBits
+
+import (
+	"database/sql"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testModel A DbModeler for tests using the given driver info.
+type testModel struct {
+	meta *DriverInfo
+}
+
+func (m testModel) GetDbMeta() *DriverInfo { return m.meta }
+func (m testModel) InTransaction() bool { return false }
+func (m testModel) BeginTransaction() {}
+func (m testModel) CommitTransaction() {}
+func (m testModel) RollbackTransaction() {}
+
+// newTestBuilder Returns a builder for the dialect as its driver info is set up
+// by SetDriverName(); only SQL Server supports named params.
+func newTestBuilder( aDriverName DriverName ) *Builder {
+	return NewBuilder(testModel{(&DriverInfo{}).SetDriverName(string(aDriverName))})
+}
+
+// errorsOf Returns the messages of the builder's errors.
+func errorsOf( aBuilder *Builder ) []string {
+	var theMessages []string
+	for _, err := range aBuilder.GetErrors() {
+		theMessages = append(theMessages, err.Error())
+	}
+	return theMessages
+}
+
+// sqlTest A builder producing an expected statement, see runSqlTests().
+type sqlTest struct {
+	name    string
+	build   func() *Builder
+	want    string
+	wantErr string
+}
+
+// runSqlTests Checks the GetSQLStatement() of each built builder as well as any
+// error it recorded, which must contain wantErr if set.
+func runSqlTests( t *testing.T, aTests []sqlTest ) {
+	t.Helper()
+	for _, tt := range aTests {
+		t.Run(tt.name, func( t *testing.T ) {
+			b := tt.build()
+			if got := b.GetSQLStatement(); got != tt.want {
+				t.Errorf("got SQL\n\t%q\nwant\n\t%q", got, tt.want)
+			}
+			theErrs := strings.Join(errorsOf(b), "; ")
+			switch {
+			case tt.wantErr == "" && theErrs != "":
+				t.Errorf("unexpected errors: %s", theErrs)
+			case tt.wantErr != "" && !strings.Contains(theErrs, tt.wantErr):
+				t.Errorf("got errors %q, want one containing %q", theErrs, tt.wantErr)
+			}//switch
+		})
+	}
+}
+
+func TestAddCaseInsensitiveLikeParam( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("n", "a%").AddCaseInsensitiveLikeParam("name", "n")
+		}, `SELECT * FROM t WHERE "name" ILIKE :n`, ""},
+		{"mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("n", "a%").AddCaseInsensitiveLikeParam("name", "n")
+		}, "SELECT * FROM t WHERE LOWER(`name`) LIKE LOWER(:n)", ""},
+		{"sqlite", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("n", "a%").AddCaseInsensitiveLikeParam("name", "n")
+		}, `SELECT * FROM t WHERE LOWER("name") LIKE LOWER(:n)`, ""},
+	})
+}
+
+func TestUnion( t *testing.T ) {
+	newQuery := func( aTable string ) *Builder {
+		return newTestBuilder(PostgreSQL).StartWith("SELECT id FROM " + aTable).StartWhereClause().
+			SetParam("id", aTable).MustAddParam("id")
+	}
+	runSqlTests(t, []sqlTest{
+		{"union", func() *Builder {
+			return newQuery("a").Union(newQuery("b"))
+		}, `SELECT id FROM a WHERE "id"=:id UNION SELECT id FROM b WHERE "id"=:id2`, ""},
+		{"union all", func() *Builder {
+			return newQuery("a").UnionAll(newQuery("b")).Add("ORDER BY id")
+		}, `SELECT id FROM a WHERE "id"=:id UNION ALL SELECT id FROM b WHERE "id"=:id2 ORDER BY id`, ""},
+	})
+	b := newQuery("a").Union(newQuery("b"))
+	if *b.GetParam("id") != "a" || *b.GetParam("id2") != "b" {
+		t.Errorf("got params %v", b.SQLparams())
+	}
+}
+
+func TestAddConcatField( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT").AddConcatField([]string{"first", ":sep", "last"}, "name")
+		}, "SELECT CONCAT(`first`, :sep, `last`) AS `name`", ""},
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").AddConcatField([]string{"first", "last"}, "")
+		}, `SELECT "first" || "last"`, ""},
+	})
+}
+
+func TestClearWhere( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"clear where", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").SetParam("x", "1").
+				StartWhereClause().SetParam("a", "1").MustAddParam("a").EndWhereClause().ClearWhere()
+		}, `SELECT * FROM t`, ""},
+		{"clear where then rebuild", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").
+				StartWhereClause().SetParam("a", "1").MustAddParam("a").ClearWhere().
+				StartWhereClause().SetParam("b", "2").MustAddParam("b")
+		}, `SELECT * FROM t WHERE "b"=:b`, ""},
+	})
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").SetParam("x", "1").
+		StartWhereClause().SetParam("a", "1").MustAddParam("a").ClearWhere()
+	if b.GetParam("a") != nil || b.GetParam("x") == nil {
+		t.Errorf("ClearWhere() left params %v", b.SQLparams())
+	}
+}
+
+func TestParens( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"group", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").SetParam("b", "2").SetParam("c", "3").
+				OpenParen().MustAddParam("a").Or().MustAddParam("b").CloseParen().
+				And().MustAddParam("c")
+		}, `SELECT * FROM t WHERE ("a"=:a OR "b"=:b) AND "c"=:c`, ""},
+		{"close restores the outer glue", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").SetParam("b", "2").SetParam("c", "3").SetParam("d", "4").
+				MustAddParam("a").And().
+				OpenParen().MustAddParam("b").Or().MustAddParam("c").CloseParen().
+				MustAddParam("d")
+		}, `SELECT * FROM t WHERE "a"=:a AND ("b"=:b OR "c"=:c) AND "d"=:d`, ""},
+		{"nested", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").SetParam("b", "2").SetParam("c", "3").
+				OpenParen().MustAddParam("a").Or().
+				OpenParen().MustAddParam("b").And().MustAddParam("c").CloseParen().
+				CloseParen()
+		}, `SELECT * FROM t WHERE ("a"=:a OR ("b"=:b AND "c"=:c))`, ""},
+		{"filter group", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				ApplyFilterGroup(newTestBuilder(PostgreSQL).SetParamPrefix("").SetParam("a", "1").MustAddParam("a"))
+		}, `SELECT * FROM t WHERE ("a"=:a)`, ""},
+	})
+	tests := []struct {
+		name string
+		call func( b *Builder )
+	}{
+		{"unmatched close", func( b *Builder ) { b.CloseParen() }},
+		{"unclosed SQL", func( b *Builder ) { b.OpenParen().SQL() }},
+		{"unclosed Rebind", func( b *Builder ) { b.OpenParen().Rebind(BindDollar) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tt.call(newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t"))
+		})
+	}
+}
+
+func TestAddJsonField( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"postgres key", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").AddJsonField("data", "city", "c")
+		}, `SELECT "data"->>'city' AS "c"`, ""},
+		{"postgres path", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").AddJsonField("data", "$.a.0.b", "")
+		}, `SELECT "data"#>>'{a,0,b}'`, ""},
+		{"mysql path", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT").AddJsonField("data", "a.0.b", "")
+		}, "SELECT JSON_UNQUOTE(JSON_EXTRACT(`data`, '$.a[0].b'))", ""},
+		{"sqlite path", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT").AddJsonField("data", "a.b", "")
+		}, `SELECT json_extract("data", '$.a.b')`, ""},
+		{"sqlite without JSON1", func() *Builder {
+			b := newTestBuilder(SQLite)
+			b.myDbModel.GetDbMeta().SupportsJSON1 = false
+			return b.StartWith("SELECT").AddJsonField("data", "a.b", "")
+		}, `SELECT`, "JSON1"},
+		{"sql server", func() *Builder {
+			return newTestBuilder(MSSQL).StartWith("SELECT").AddJsonField("data", "a", "")
+		}, `SELECT`, "not supported"},
+		{"invalid path", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").AddJsonField("data", "a'; DROP", "")
+		}, `SELECT`, "invalid JSON path"},
+		{"where param", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("c", "Oslo").AddParamJsonPath("data", "address.city", "c")
+		}, "SELECT * FROM t WHERE JSON_UNQUOTE(JSON_EXTRACT(`data`, '$.address.city'))=:c", ""},
+	})
+}
+
+func TestAddParamNullSafeEquals( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetNullableParam("a", nil).AddParamNullSafeEquals("a", "a")
+		}, "SELECT * FROM t WHERE `a` <=> :a", ""},
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").AddParamNullSafeEquals("a", "a")
+		}, `SELECT * FROM t WHERE "a" IS NOT DISTINCT FROM :a`, ""},
+		{"sqlite", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").AddParamNullSafeEquals("a", "a")
+		}, `SELECT * FROM t WHERE "a" IS NOT DISTINCT FROM :a`, ""},
+	})
+}
+
+func TestAddUpdateFrom( t *testing.T ) {
+	newOn := func( aDriverName DriverName ) *Builder {
+		return newTestBuilder(aDriverName).StartWith("u.tid = t.id")
+	}
+	runSqlTests(t, []sqlTest{
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("UPDATE t SET a = 1").AddUpdateFrom("u", newOn(PostgreSQL)).
+				SetParam("b", "2").MustAddParam("b")
+		}, `UPDATE t SET a = 1 FROM "u" WHERE (u.tid = t.id) AND "b"=:b`, ""},
+		{"mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("UPDATE t SET a = 1").AddUpdateFrom("u", newOn(MySQL)).
+				SetParam("b", "2").MustAddParam("b")
+		}, "UPDATE t JOIN `u` ON (u.tid = t.id) SET a = 1 WHERE `b`=:b", ""},
+	})
+}
+
+func TestAddFieldListWithAliases( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"aliases", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").
+				AddFieldListWithAliases(map[string]string{"b": "", "a": "x"}).Add("FROM t")
+		}, `SELECT  "a" AS "x",  "b" FROM t`, ""},
+		{"no fields", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").AddFieldListWithAliases(nil).Add("FROM t")
+		}, `SELECT  * FROM t`, ""},
+		{"quoted field list", func() *Builder {
+			return newTestBuilder(MySQL).SetFieldListQuoting(true).StartWith("SELECT").
+				AddFieldList(&[]string{"a", "order"}).Add("FROM t")
+		}, "SELECT  `a`,  `order` FROM t", ""},
+		{"unquoted field list", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").AddFieldList(&[]string{"a", "b"}).Add("FROM t")
+		}, `SELECT  a,  b FROM t`, ""},
+	})
+}
+
+func TestAddDeleteUsing( t *testing.T ) {
+	newOn := func( aDriverName DriverName ) *Builder {
+		return newTestBuilder(aDriverName).StartWith("u.tid = t.id")
+	}
+	runSqlTests(t, []sqlTest{
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("DELETE FROM t").AddDeleteUsing("u", newOn(PostgreSQL))
+		}, `DELETE FROM t USING "u" WHERE (u.tid = t.id)`, ""},
+		{"mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("DELETE FROM t").AddDeleteUsing("u", newOn(MySQL)).
+				SetParam("b", "2").MustAddParam("b")
+		}, "DELETE t FROM t JOIN `u` ON (u.tid = t.id) WHERE `b`=:b", ""},
+		{"mysql keeps modifiers and alias", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("DELETE LOW_PRIORITY IGNORE FROM t AS x").
+				AddDeleteUsing("u", nil)
+		}, "DELETE LOW_PRIORITY IGNORE x FROM t AS x JOIN `u` ON (true)", ""},
+		{"mysql needs a table", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("DELETE").AddDeleteUsing("u", nil)
+		}, "DELETE", "requires a DELETE FROM table"},
+		{"sqlite", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("DELETE FROM t").AddDeleteUsing("u", newOn(SQLite))
+		}, `DELETE FROM t WHERE EXISTS (SELECT 1 FROM "u" WHERE u.tid = t.id)`, ""},
+	})
+}
+
+func TestOperatorNormalization( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"not equal", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("!=").SetParam("id", "1").MustAddParam("id")
+		}, `SELECT * FROM t WHERE "id"<>:id`, ""},
+		{"keyword", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("not  like").SetParam("n", "a%").MustAddParam("n")
+		}, `SELECT * FROM t WHERE "n" NOT LIKE :n`, ""},
+		{"LIKE any", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("like").SetParamSet("n", &[]string{"a%", "b%"}).MustAddParam("n")
+		}, `SELECT * FROM t WHERE ("n" LIKE :n_1 OR "n" LIKE :n_2)`, ""},
+		{"NOT LIKE all", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("NOT LIKE").SetParamSet("n", &[]string{"a%", "b%"}).MustAddParam("n")
+		}, `SELECT * FROM t WHERE ("n" NOT LIKE :n_1 AND "n" NOT LIKE :n_2)`, ""},
+		{"BETWEEN", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("between").SetParamSet("d", &[]string{"1", "9"}).MustAddParam("d")
+		}, `SELECT * FROM t WHERE "d" BETWEEN :d_1 AND :d_2`, ""},
+		{"BETWEEN needs 2 values", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("BETWEEN").SetParamSet("d", &[]string{"1"}).MustAddParam("d")
+		}, `SELECT * FROM t`, "requires exactly 2 values"},
+	})
+}
+
+func TestGetQuotedQualified( t *testing.T ) {
+	tests := []struct {
+		name   string
+		driver DriverName
+		ident  string
+		want   string
+	}{
+		{"postgres", PostgreSQL, "public.users", `"public"."users"`},
+		{"mysql", MySQL, "db.users", "`db`.`users`"},
+		{"unqualified", SQLite, "users", `"users"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if got := newTestBuilder(tt.driver).GetQuotedQualified(tt.ident); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTypedParam( t *testing.T ) {
+	b := newTestBuilder(SQLite).StartWith("SELECT * FROM t WHERE a = :a AND b = :b AND c = :c").
+		SetTypedParam("a", 5).SetTypedParam("b", true).SetNullableParam("c", nil)
+	if got := b.SQLargs(); !reflect.DeepEqual(got, []interface{}{5, true, nil}) {
+		t.Errorf("got args %#v", got)
+	}
+}
+
+// argsTest A builder producing an expected SQL() and SQLargs(), see runArgsTests().
+type argsTest struct {
+	name     string
+	build    func() *Builder
+	wantSql  string
+	wantArgs []interface{}
+}
+
+// runArgsTests Checks that each built builder repeatedly returns the same SQL()
+// and SQLargs(), and that AssertArgsMatch() agrees.
+func runArgsTests( t *testing.T, aTests []argsTest ) {
+	t.Helper()
+	for _, tt := range aTests {
+		t.Run(tt.name, func( t *testing.T ) {
+			b := tt.build()
+			for i := 0; i < 2; i += 1 {
+				// args must not accumulate across calls
+				if got := b.SQL(); got != tt.wantSql {
+					t.Errorf("call %d got %q, want %q", i+1, got, tt.wantSql)
+				}
+				if got := b.SQLargs(); !reflect.DeepEqual(got, tt.wantArgs) {
+					t.Errorf("call %d got args %#v, want %#v", i+1, got, tt.wantArgs)
+				}
+			}
+			if err := b.AssertArgsMatch(); err != nil {
+				t.Errorf("unexpected mismatch: %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLargsDoNotAccumulate( t *testing.T ) {
+	runArgsTests(t, []argsTest{
+		{"params", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE a = :a").SetParam("a", "1")
+		}, `SELECT * FROM t WHERE a = $1`, []interface{}{"1"}},
+		{"NULL arg", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE a = :a").SetNullableParam("a", nil)
+		}, `SELECT * FROM t WHERE a = $1`, []interface{}{nil}},
+		{"no params", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT 1")
+		}, `SELECT 1`, nil},
+	})
+}
+
+func TestOrdinalParamsInSQLOrder( t *testing.T ) {
+	runArgsTests(t, []argsTest{
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("z", "1").SetParam("a", "2").MustAddParam("z").And().MustAddParam("a")
+		}, `SELECT * FROM t WHERE "z"=$1 AND "a"=$2`, []interface{}{"1", "2"}},
+		{"sets", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamSet("z", &[]string{"3", "4"}).SetParam("a", "2").MustAddParam("z").And().MustAddParam("a")
+		}, `SELECT * FROM t WHERE "z" IN ($1,$2) AND "a"=$3`, []interface{}{"3", "4", "2"}},
+	})
+}
+
+func TestRepeatedOrdinalParams( t *testing.T ) {
+	runArgsTests(t, []argsTest{
+		{"postgres reuses repeated params", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE a = :x OR b = :y OR c = :x").
+				SetParam("x", "1").SetParam("y", "2")
+		}, `SELECT * FROM t WHERE a = $1 OR b = $2 OR c = $1`, []interface{}{"1", "2"}},
+		{"question marks repeat args", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT * FROM t WHERE a = :x OR b = :y OR c = :x").
+				SetParam("x", "1").SetParam("y", "2")
+		}, `SELECT * FROM t WHERE a = ? OR b = ? OR c = ?`, []interface{}{"1", "2", "1"}},
+	})
+}
+
+func TestResetSQL( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().SetParam("a", "1").
+		MustAddParam("a").ResetSQL()
+	if b.GetSQLStatement() != "" || b.GetParam("a") == nil {
+		t.Errorf("ResetSQL() got %q with params %v", b.GetSQLStatement(), b.SQLparams())
+	}
+	b.StartWith("SELECT * FROM u WHERE a = :a")
+	if got := b.SQLargs(); !reflect.DeepEqual(got, []interface{}{"1"}) {
+		t.Errorf("got args %v after ResetSQL()", got)
+	}
+}
+
+func TestNullSafeEqualParams( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"alias", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetNullableParam("a", nil).AddNullSafeEqualParam("a", "a")
+		}, "SELECT * FROM t WHERE `a` <=> :a", ""},
+		{"ignores a leftover operator", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("<>").SetParam("b", "2").MustAddParam("b").And().
+				SetParam("a", "1").AddParamNullSafeEquals("a", "a")
+		}, `SELECT * FROM t WHERE "b"<>:b AND "a" IS NOT DISTINCT FROM :a`, ""},
+		{"mysql not equals", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetNullableParam("a", nil).AddParamNullSafeNotEquals("a", "a")
+		}, "SELECT * FROM t WHERE NOT (`a` <=> :a)", ""},
+		{"postgres not equals", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").AddParamNullSafeNotEquals("a", "a")
+		}, `SELECT * FROM t WHERE "a" IS DISTINCT FROM :a`, ""},
+		{"sqlite not equals", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").AddParamNullSafeNotEquals("a", "a")
+		}, `SELECT * FROM t WHERE "a" IS DISTINCT FROM :a`, ""},
+	})
+}
+
+func TestDataSourceParams( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"map", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetDataSource(MapDataSource{"a": {"1"}, "s": {"x", "y"}}).
+				StartWith("SELECT * FROM t").StartWhereClause().AddParamIfDefined("a").And().
+				AddParamIfDefined("b").And().AddParamIfDefined("s")
+		}, `SELECT * FROM t WHERE "a"=:a AND "s" IN (:s_1,:s_2)`, ""},
+		{"url values", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetDataSource(URLValuesDataSource{"a": {"1"}}).
+				StartWith("SELECT * FROM t").StartWhereClause().AddParamIfDefined("a").And().AddParamIfDefined("b")
+		}, `SELECT * FROM t WHERE "a"=:a`, ""},
+	})
+}
+
+func TestValidate( t *testing.T ) {
+	tests := []struct {
+		name    string
+		build   func() *Builder
+		wantErr string
+	}{
+		{"bound", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE a = :a").SetParam("a", "1")
+		}, ""},
+		{"unbound", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE a = :a AND b = :b")
+		}, ":a, :b"},
+		{"quoted text is not a param", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE a = ':x'")
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			err := tt.build().Validate()
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+			b := tt.build().SetValidateOnSQL(true)
+			b.SQL()
+			if (len(b.GetErrors()) > 0) != (tt.wantErr != "") {
+				t.Errorf("SQL() recorded errors %v", b.GetErrors())
+			}
+		})
+	}
+}
+
+func TestTypeCastsAreNotParams( t *testing.T ) {
+	runArgsTests(t, []argsTest{
+		{"casts and quotes are kept", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT d::date, ':a' FROM t WHERE a = :a::int").SetParam("a", "1")
+		}, `SELECT d::date, ':a' FROM t WHERE a = $1::int`, []interface{}{"1"}},
+	})
+	if err := newTestBuilder(PostgreSQL).StartWith("SELECT created::date FROM t").Validate(); err != nil {
+		t.Errorf("a cast was taken for a param: %v", err)
+	}
+}
+
+func TestApplyOrderByList( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"quoted and sorted by field name", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").
+				ApplyOrderByList(&OrderByList{"b": "desc", "a": "", "t.order": ORDER_BY_ASCENDING})
+		}, `SELECT * FROM t ORDER BY "a" ASC,"b" DESC,"t"."order" ASC`, ""},
+		{"mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").ApplyOrderByList(&OrderByList{"a`b": "DESC"})
+		}, "SELECT * FROM t ORDER BY `a``b` DESC", ""},
+	})
+}
+
+func TestSanitizer( t *testing.T ) {
+	theSanitizer := testSanitizer{
+		fields:   []string{"id", "name"},
+		sortable: map[string]bool{"id": true, "name": true, "name_len": true},
+		defSort:  OrderByList{"id": "ASC"},
+	}
+	newBuilder := func() *Builder {
+		return newTestBuilder(PostgreSQL).SetSanitizer(theSanitizer).StartWith("SELECT")
+	}
+	runSqlTests(t, []sqlTest{
+		{"malicious fields are dropped", func() *Builder {
+			return newBuilder().AddFieldList(&[]string{"name", "1; DROP TABLE t"}).Add("FROM t")
+		}, `SELECT  name FROM t`, ""},
+		{"all fields pruned falls back to the defined fields", func() *Builder {
+			return newBuilder().AddFieldList(&[]string{"secret"}).Add("FROM t")
+		}, `SELECT  id,  name FROM t`, ""},
+		{"aliased fields pruned falls back to the defined fields", func() *Builder {
+			return newBuilder().AddFieldListWithAliases(map[string]string{"secret": "s"}).Add("FROM t")
+		}, `SELECT  id,  name FROM t`, ""},
+		{"all fields pruned without defined fields", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetSanitizer(testSanitizer{}).StartWith("SELECT").
+				AddFieldList(&[]string{"secret"})
+		}, `SELECT`, "pruned every field"},
+		{"malicious sort is dropped", func() *Builder {
+			return newBuilder().Add("* FROM t").ApplyOrderBySequence(OrderBySequence{{"name", "DESC"}, {"x;--", "ASC"}})
+		}, `SELECT * FROM t ORDER BY "name" DESC`, ""},
+		{"default sort", func() *Builder {
+			return newBuilder().Add("* FROM t").ApplyOrderByList(&OrderByList{"x;--": "ASC"})
+		}, `SELECT * FROM t ORDER BY "id" ASC`, ""},
+	})
+}
+
+func TestOrderByNulls( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").
+				ApplyOrderBySequence(OrderBySequence{{"a", "desc nulls last"}, {"b", "NULLS FIRST"}})
+		}, `SELECT * FROM t ORDER BY "a" DESC NULLS LAST,"b" ASC NULLS FIRST`, ""},
+		{"mysql drops them", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").
+				ApplyOrderBySequence(OrderBySequence{{"a", "DESC NULLS LAST"}})
+		}, "SELECT * FROM t ORDER BY `a` DESC", ""},
+	})
+}
+
+func TestDebugSQL( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+		SetParam("n", "it's").MustAddParam("n").And().SetTypedParam("i", 5).MustAddParam("i").And().
+		SetParamSet("s", &[]string{"a", "b"}).MustAddParam("s")
+	theWant := `SELECT * FROM t WHERE "n"='it''s' AND "i"=5 AND "s" IN ('a','b')`
+	if got := b.DebugSQL(); got != theWant {
+		t.Errorf("got %q, want %q", got, theWant)
+	}
+}
+
+func TestSetMaxInListSize( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"IN list", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamSet("id", &[]string{"1", "2"}).MustAddParam("id")
+		}, `SELECT * FROM t WHERE "id" IN (:id_1,:id_2)`, ""},
+		{"chunked IN list", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetMaxInListSize(2).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamSet("id", &[]string{"1", "2", "3"}).MustAddParam("id")
+		}, `SELECT * FROM t WHERE ("id" IN (:id_1,:id_2) OR "id" IN (:id_3))`, ""},
+		{"chunked NOT IN list", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetMaxInListSize(1).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("NOT IN").SetParamSet("id", &[]string{"1", "2"}).MustAddParam("id")
+		}, `SELECT * FROM t WHERE ("id" NOT IN (:id_1) AND "id" NOT IN (:id_2))`, ""},
+	})
+}
+
+func TestEmptyParamSets( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"empty IN", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamSet("id", &[]string{}).MustAddParam("id")
+		}, "SELECT * FROM t WHERE false", ""},
+		{"empty NOT IN", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("<>").SetParamSet("id", nil).MustAddParam("id")
+		}, "SELECT * FROM t WHERE true", ""},
+		{"bad operator", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator(">").SetParamSet("id", nil).MustAddParam("id")
+		}, "SELECT * FROM t", "empty value set"},
+	})
+}
+
+func TestUnquote( t *testing.T ) {
+	tests := []struct {
+		name   string
+		driver DriverName
+		ident  string
+		want   string
+	}{
+		{"postgres", PostgreSQL, `"a""b"`, `a"b`},
+		{"mysql", MySQL, "`a``b`", "a`b"},
+		{"unquoted", MySQL, "plain", "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			b := newTestBuilder(tt.driver)
+			if got := b.Unquote(tt.ident); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if got := b.Unquote(b.GetQuoted(tt.want)); got != tt.want {
+				t.Errorf("round trip got %q", got)
+			}
+		})
+	}
+}
+
+func TestAddCoalesceField( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"coalesce", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").SetParam("d", "0").AddCoalesceField("n", "d", "n")
+		}, `SELECT COALESCE("n", :d) AS "n"`, ""},
+		{"ifnull", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT").SetParam("d", "0").AddCoalesceField("n", "d", "")
+		}, "SELECT IFNULL(`n`, :d)", ""},
+	})
+}
+
+func TestParamCount( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause()
+	if b.HasParams() || b.ParamCount() != 0 {
+		t.Errorf("new builder has %d params", b.ParamCount())
+	}
+	b.SetParam("a", "1").MustAddParam("a").And().SetParamSet("s", &[]string{"x", "y"})
+	if got := b.ParamCount(); got != 3 {
+		t.Errorf("before expansion got %d params, want 3", got)
+	}
+	b.MustAddParam("s")
+	if got := b.ParamCount(); got != 3 {
+		t.Errorf("after expansion got %d params, want 3", got)
+	}
+}
+
+func TestRemoveParam( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+		SetParam("a", "1").MustAddParam("a").And().SetParamSet("s", &[]string{"x", "y"}).MustAddParam("s")
+	b.RemoveParamSet("s")
+	if got := b.ParamCount(); got != 1 || b.GetParam("s_1") != nil || b.IsParamASet("s") {
+		t.Errorf("after RemoveParamSet() got %d params", got)
+	}
+	b.RemoveParam("a")
+	if b.HasParams() {
+		t.Errorf("after RemoveParam() got %d params", b.ParamCount())
+	}
+	if got := b.SetParam("k", "1").SetParam("k", "2").GetParam("k"); *got != "2" {
+		t.Errorf("override got %q", *got)
+	}
+}
+
+func TestAddParamAsArray( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamSet("id", &[]string{"a", `b"c`}).AddParamAsArray("id", "id")
+		}, `SELECT * FROM t WHERE "id" = ANY(:id)`, ""},
+		{"postgres not equal", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("<>").SetParamSet("id", &[]string{"a"}).AddParamAsArray("id", "id")
+		}, `SELECT * FROM t WHERE "id" <> ALL(:id)`, ""},
+		{"falls back to IN for mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamSet("id", &[]string{"a"}).AddParamAsArray("id", "id")
+		}, "SELECT * FROM t WHERE `id` IN (:id_1)", ""},
+	})
+	b := newTestBuilder(PostgreSQL).SetParamSet("id", &[]string{"a", `b"c`}).AddParamAsArray("id", "id")
+	if got := *b.GetParam("id"); got != `{"a","b\"c"}` {
+		t.Errorf("got array literal %q", got)
+	}
+}
+
+func TestApplySeekPagination( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"first page", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				ApplySeekPagination(OrderBySequence{{"a", "ASC"}}, nil, 20)
+		}, `SELECT * FROM t ORDER BY "a" ASC LIMIT 20`, ""},
+		{"single column", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				ApplySeekPagination(OrderBySequence{{"a", "DESC"}}, map[string]string{"a": "5"}, 0)
+		}, `SELECT * FROM t WHERE "a" < :seek_a ORDER BY "a" DESC`, ""},
+		{"row comparison in caller order", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				ApplySeekPagination(OrderBySequence{{"z", "ASC"}, {"a", "ASC"}}, map[string]string{"a": "1", "z": "2"}, 10)
+		}, `SELECT * FROM t WHERE ("z", "a") > (:seek_z, :seek_a) ORDER BY "z" ASC, "a" ASC LIMIT 10`, ""},
+		{"mixed directions", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				ApplySeekPagination(OrderBySequence{{"a", "ASC"}, {"t.b", "DESC"}}, map[string]string{"a": "1", "t.b": "2"}, 0)
+		}, `SELECT * FROM t WHERE (("a" > :seek_a) OR ("a" = :seek_a AND "t"."b" < :seek_t_b)) ORDER BY "a" ASC, "t"."b" DESC`, ""},
+		{"missing seek value", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").
+				ApplySeekPagination(OrderBySequence{{"a", "ASC"}, {"b", "ASC"}}, map[string]string{"a": "1"}, 0)
+		}, `SELECT * FROM t`, "missing seek value"},
+		{"no order by", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").ApplySeekPagination(nil, nil, 0)
+		}, `SELECT * FROM t`, "requires an order by list"},
+		{"sanitized", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetSanitizer(testSanitizer{defSort: OrderByList{"id": "ASC"}}).
+				StartWith("SELECT * FROM t").ApplySeekPagination(OrderBySequence{{"x;--", "ASC"}}, nil, 0)
+		}, `SELECT * FROM t ORDER BY "id" ASC`, ""},
+	})
+}
+
+// newNamedTestBuilder Returns a builder for the dialect whose driver supports
+// named params, so that SQL() keeps the ":name" params as built.
+func newNamedTestBuilder( aDriverName DriverName ) *Builder {
+	theMeta := (&DriverInfo{}).SetDriverName(string(aDriverName))
+	theMeta.SupportsNamedParams = true
+	return NewBuilder(testModel{theMeta})
+}
+
+func TestRebind( t *testing.T ) {
+	tests := []struct {
+		name     string
+		style    BindStyle
+		wantSql  string
+		wantArgs []interface{}
+	}{
+		{"question", BindQuestion, `a = ? OR b = ? OR c = ?`, []interface{}{"1", "2", "1"}},
+		{"dollar", BindDollar, `a = $1 OR b = $2 OR c = $1`, []interface{}{"1", "2"}},
+		{"at", BindAt, `a = @p1 OR b = @p2 OR c = @p1`, []interface{}{"1", "2"}},
+		{"named", BindNamed, `a = :x OR b = :y OR c = :x`, []interface{}{sql.Named("x", "1"), sql.Named("y", "2")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			b := newNamedTestBuilder(MySQL).StartWith("a = :x OR b = :y OR c = :x").SetParam("x", "1").SetParam("y", "2")
+			theSql, theArgs := b.Rebind(tt.style)
+			if theSql != tt.wantSql || !reflect.DeepEqual(theArgs, tt.wantArgs) {
+				t.Errorf("got %q %#v, want %q %#v", theSql, theArgs, tt.wantSql, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestAddForUpdate( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"for update", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddForUpdate()
+		}, `SELECT * FROM t FOR UPDATE`, ""},
+		{"skip locked", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").AddForUpdateSkipLocked()
+		}, `SELECT * FROM t FOR UPDATE SKIP LOCKED`, ""},
+		{"no row locks for sqlite", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT * FROM t").AddForUpdate()
+		}, `SELECT * FROM t`, ""},
+	})
+}
+
+func TestInserts( t *testing.T ) {
+	theRows := []IDataSource{
+		MapDataSource{"a": {"1"}, "b": {"2"}},
+		MapDataSource{"a": {"3"}},
+	}
+	runSqlTests(t, []sqlTest{
+		{"insert values", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("INSERT INTO t").AddInsertValues([]string{"a", "b"}, theRows)
+		}, `INSERT INTO t ("a", "b") VALUES (:a, :b), (:a2, :b2)`, ""},
+		{"insert NULL literals", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("INSERT INTO t").StartSetClause().
+				AddInsertValues([]string{"a", "b"}, theRows)
+		}, `INSERT INTO t ("a", "b") VALUES (:a, :b), (:a2, NULL)`, ""},
+	})
+	// a driver binding at most 2 params gets a row per statement
+	theMeta := (&DriverInfo{}).SetDriverName(string(PostgreSQL))
+	theMeta.MaxBindParams = 2
+	theBatches := NewBuilder(testModel{theMeta}).BuildBatchInserts("s.t", []string{"a", "b"}, theRows)
+	var theSql []string
+	for _, b := range theBatches {
+		theSql = append(theSql, b.GetSQLStatement())
+	}
+	theWant := []string{`INSERT INTO "s"."t" ("a", "b") VALUES (:a, :b)`, `INSERT INTO "s"."t" ("a", "b") VALUES (:a, :b)`}
+	if !reflect.DeepEqual(theSql, theWant) {
+		t.Errorf("got batches %q, want %q", theSql, theWant)
+	}
+	if *theBatches[1].GetParam("a") != "3" {
+		t.Errorf("second batch got params %v", theBatches[1].SQLparams())
+	}
+	theManyRows := make([]IDataSource, 1000)
+	for i := range theManyRows {
+		theManyRows[i] = MapDataSource{"a": {"1"}, "b": {"2"}, "c": {"3"}}
+	}
+	tests := []struct {
+		name      string
+		driver    DriverName
+		wantBatch []int
+	}{
+		{"mysql", MySQL, []int{3000}},
+		{"postgres", PostgreSQL, []int{3000}},
+		{"sqlite", SQLite, []int{999, 999, 999, 3}},
+		{"sql server", MSSQL, []int{2100, 900}},
+		{"unknown", "Exotic", []int{3000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			// the IN list size does not limit the batches
+			b := newTestBuilder(tt.driver).SetMaxInListSize(10)
+			var theParamCounts []int
+			for _, theBatch := range b.BuildBatchInserts("t", []string{"a", "b", "c"}, theManyRows) {
+				theParamCounts = append(theParamCounts, theBatch.ParamCount())
+			}
+			if !reflect.DeepEqual(theParamCounts, tt.wantBatch) {
+				t.Errorf("got batches binding %v params, want %v", theParamCounts, tt.wantBatch)
+			}
+		})
+	}
+}
+
+func TestSetLogger( t *testing.T ) {
+	var theSql []string
+	var theArgs [][]interface{}
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE a = :a").SetParam("a", "1").
+		SetLogger(func( aSql string, aArgs []interface{} ) {
+			theSql = append(theSql, aSql)
+			theArgs = append(theArgs, aArgs)
+		})
+	b.SQL()
+	b.Reset()
+	b.StartWith("SELECT 2").SQL()
+	if !reflect.DeepEqual(theSql, []string{"SELECT * FROM t WHERE a = $1", "SELECT 2"}) {
+		t.Errorf("logged %q", theSql)
+	}
+	if !reflect.DeepEqual(theArgs[0], []interface{}{"1"}) {
+		t.Errorf("logged args %v", theArgs[0])
+	}
+}
+
+func TestDbMetaDefaults( t *testing.T ) {
+	tests := []struct {
+		name string
+		meta *DriverInfo
+		want string
+	}{
+		{"nil driver info", nil, `SELECT  "a" FROM t WHERE "id"=:id`},
+		{"unknown driver", &DriverInfo{Name: "Exotic", SupportsNamedParams: true}, `SELECT  "a" FROM t WHERE "id"=:id`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			b := NewBuilder(testModel{tt.meta}).StartWith("SELECT").SetFieldListQuoting(true).
+				AddFieldList(&[]string{"a"}).Add("FROM t").StartWhereClause().SetParam("id", "1").MustAddParam("id")
+			if got := b.GetSQLStatement(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddSubQueryForColumn( t *testing.T ) {
+	newSub := func() *Builder {
+		return newTestBuilder(PostgreSQL).StartWith("SELECT uid FROM o").StartWhereClause().
+			SetParam("id", "2").MustAddParam("id")
+	}
+	runSqlTests(t, []sqlTest{
+		{"IN", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				AddSubQueryForColumn(newSub(), "id")
+		}, `SELECT * FROM t WHERE "id" IN (SELECT uid FROM o WHERE "id"=:id)`, ""},
+		{"NOT IN", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("<>").AddSubQueryForColumn(newSub(), "id")
+		}, `SELECT * FROM t WHERE "id" NOT IN (SELECT uid FROM o WHERE "id"=:id)`, ""},
+	})
+}
+
+func TestApplyFilterOr( t *testing.T ) {
+	newClause := func( aKey string, aValue string ) *Builder {
+		return newTestBuilder(PostgreSQL).SetParamPrefix("").SetParam(aKey, aValue).MustAddParam(aKey)
+	}
+	runSqlTests(t, []sqlTest{
+		{"nil filter", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").ApplyFilterOr(nil)
+		}, `SELECT * FROM t`, ""},
+		{"after where clauses", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").SetParam("b", "2").MustAddParam("a").And().MustAddParam("b").
+				ApplyFilterOr(newClause("c", "3"))
+		}, `SELECT * FROM t WHERE ("a"=:a AND "b"=:b) OR ("c"=:c)`, ""},
+		{"right after StartWhereClause", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				ApplyFilterOr(newClause("c", "3"))
+		}, `SELECT * FROM t WHERE ("c"=:c)`, ""},
+		{"of filters", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartFilter().SetParam("a", "1").MustAddParam("a").
+				ApplyFilterOr(newClause("c", "3"))
+		}, ` (true AND "a"=:a) OR ("c"=:c)`, ""},
+		{"replaces the StartFilter placeholder", func() *Builder {
+			return newTestBuilder(MySQL).StartFilter().ApplyFilterOr(
+				newTestBuilder(MySQL).SetParamPrefix("").SetParam("c", "3").MustAddParam("c"))
+		}, "(`c`=:c)", ""},
+		{"after EndWhereClause", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("a", "1").SetParam("b", "2").MustAddParam("a").And().MustAddParam("b").
+				EndWhereClause().Add("ORDER BY a").ApplyFilterOr(newClause("c", "3"))
+		}, `SELECT * FROM t WHERE ("a"=:a AND "b"=:b) OR ("c"=:c) ORDER BY a`, ""},
+		{"WHERE prefix without StartWhereClause", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").SetParamPrefix(" WHERE ").
+				SetParam("a", "1").MustAddParam("a").ApplyFilterOr(newClause("c", "3"))
+		}, `SELECT * FROM t WHERE "a"=:a`, "requires a WHERE clause"},
+		{"no WHERE clause", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").ApplyFilterOr(newClause("c", "3"))
+		}, `SELECT * FROM t`, "requires a WHERE clause"},
+	})
+	t.Run("ClearWhere after a closed clause", func( t *testing.T ) {
+		b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+			SetParam("a", "1").MustAddParam("a").EndWhereClause().
+			ApplyFilterOr(newClause("c", "3")).ClearWhere()
+		if got, want := b.SQL(), `SELECT * FROM t`; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if got := b.ParamCount(); got != 0 {
+			t.Errorf("ParamCount() got %d, want 0", got)
+		}
+	})
+}
+
+func TestGetNormalizedSQL( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("  SELECT\n\t*  FROM t\r\n WHERE a = '  x  '  ")
+	if got, want := b.GetNormalizedSQL(), `SELECT * FROM t WHERE a = '  x  '`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAddExpressionParam( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"expression", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("n", "Bob").AddExpressionParam("lower(name) = lower(?) AND x <> '?'", "n")
+		}, `SELECT * FROM t WHERE lower(name) = lower(:n) AND x <> '?'`, ""},
+		{"without marker", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddExpressionParam("a = 1", "n")
+		}, `SELECT * FROM t`, "no \"?\" marker"},
+	})
+}
+
+func TestWhere( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"closure ends the clause", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").SetNullableParam("a", nil).
+				Where(func( b *Builder ) { b.MustAddParam("a") }).
+				StartSetClause().SetNullableParam("b", nil).SetParamPrefix(" , ").MustAddParam("b")
+		}, `SELECT * FROM t WHERE "a" IS NULL , "b"=NULL`, ""},
+	})
+}
+
+func TestAsExplain( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"postgres", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith(" SELECT 1").AsExplain(true)
+		}, `EXPLAIN ANALYZE SELECT 1`, ""},
+		{"mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT 1").AsExplain(false)
+		}, `EXPLAIN SELECT 1`, ""},
+		{"sqlite", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT 1").AsExplain(true)
+		}, `EXPLAIN QUERY PLAN SELECT 1`, ""},
+	})
+}
+
+func TestAddTupleInParam( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"IN", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				AddTupleInParam([]string{"a", "t.b"}, [][]string{{"1", "2"}, {"3", "4"}})
+		}, `SELECT * FROM t WHERE ("a", "t"."b") IN ((:a, :t_b), (:a2, :t_b2))`, ""},
+		{"NOT IN without rows", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamOperator("<>").AddTupleInParam([]string{"a"}, nil)
+		}, "SELECT * FROM t WHERE 1", ""},
+		{"row size mismatch", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").
+				AddTupleInParam([]string{"a", "b"}, [][]string{{"1"}})
+		}, `SELECT * FROM t`, "has 1 values for 2 columns"},
+	})
+	t.Run("bad later row sets no params", func( t *testing.T ) {
+		b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").
+			AddTupleInParam([]string{"a", "b"}, [][]string{{"1", "2"}, {"3"}})
+		if got := b.ParamCount(); got != 0 {
+			t.Errorf("ParamCount() got %d, want 0", got)
+		}
+		if got := len(errorsOf(b)); got != 1 {
+			t.Errorf("got %d errors, want 1", got)
+		}
+	})
+}
+
+func TestParamState( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause()
+	b.PushParamState().Or().SetParamOperator("<>").StartSetClause().PopParamState()
+	b.SetNullableParam("a", nil).MustAddParam("a")
+	if got, want := b.GetSQLStatement(), `SELECT * FROM t WHERE "a" IS NULL`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("PopParamState() without a push should panic")
+		}
+	}()
+	b.PopParamState()
+}
+
+func TestAddCorrelatedSubQueryForColumn( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"renames colliding params", func() *Builder {
+			theSub := newTestBuilder(PostgreSQL).StartWith("SELECT uid FROM o").StartWhereClause().
+				SetParam("id", "2").MustAddParam("id")
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t u").StartWhereClause().
+				SetParam("id", "1").MustAddParam("id").And().SetParamOperator("<>").
+				AddCorrelatedSubQueryForColumn(theSub, "id", "u")
+		}, `SELECT * FROM t u WHERE "id"=:id AND "u"."id" NOT IN (SELECT uid FROM o WHERE "id"=:id2)`, ""},
+	})
+}
+
+func TestBoolLiterals( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"mysql filter", func() *Builder {
+			return newTestBuilder(MySQL).StartFilter()
+		}, "1", ""},
+		{"postgres filter", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartFilter()
+		}, "true", ""},
+		{"mysql empty set", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamSet("id", &[]string{}).MustAddParam("id")
+		}, "SELECT * FROM t WHERE 0", ""},
+		{"bool param", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().AddBoolParam("on", "on", true)
+		}, "SELECT * FROM t WHERE `on`=:on", ""},
+	})
+}
+
+func TestParamSigil( t *testing.T ) {
+	b := newTestBuilder(MSSQL).StartWith("SELECT * FROM t").StartWhereClause().
+		SetParam("a", "1").MustAddParam("a").And().AddExpressionParam("b::int = ? AND c = ':a'", "a")
+	theWant := `SELECT * FROM t WHERE "a"=@a AND b::int = @a AND c = ':a'`
+	for _, got := range []string{b.SQL(), b.GetSQLStatement(), b.GetNormalizedSQL()} {
+		if got != theWant {
+			t.Errorf("got %q, want %q", got, theWant)
+		}
+	}
+	theMeta := b.myDbModel.GetDbMeta()
+	theMeta.SetDriverName(string(PostgreSQL))
+	if theMeta.ParamSigil != 0 || theMeta.SupportsNamedParams {
+		t.Errorf("SetDriverName() kept %q sigil", theMeta.ParamSigil)
+	}
+	if got, want := b.SQL(), `SELECT * FROM t WHERE "a"=$1 AND b::int = $1 AND c = ':a'`; got != want {
+		t.Errorf("after switching dialects got %q, want %q", got, want)
+	}
+}
+
+func TestSetOrderByExpressions( t *testing.T ) {
+	theSanitizer := testSanitizer{
+		sortable: map[string]bool{"id": true, "name_len": true},
+		defSort:  OrderByList{"id": "ASC"},
+	}
+	runSqlTests(t, []sqlTest{
+		{"expressions", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetOrderByExpressions(map[string]string{"len": "LENGTH(name)"}).
+				StartWith("SELECT * FROM t").ApplyOrderBySequence(OrderBySequence{{"len", "DESC"}, {"id", ""}})
+		}, `SELECT * FROM t ORDER BY LENGTH(name) DESC,"id" ASC`, ""},
+		{"approved by the sanitizer", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetSanitizer(theSanitizer).
+				SetOrderByExpressions(map[string]string{"name_len": "LENGTH(name)"}).
+				StartWith("SELECT * FROM t").ApplyOrderBySequence(OrderBySequence{{"name_len", "DESC"}})
+		}, `SELECT * FROM t ORDER BY LENGTH(name) DESC`, ""},
+		{"rejected by the sanitizer", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetSanitizer(theSanitizer).
+				SetOrderByExpressions(map[string]string{"secret_len": "LENGTH(secret)"}).
+				StartWith("SELECT * FROM t").ApplyOrderBySequence(OrderBySequence{{"secret_len", "DESC"}})
+		}, `SELECT * FROM t ORDER BY "id" ASC`, ""},
+	})
+}
+
+func TestApplyFilterNot( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"not", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				ApplyFilterNot(newTestBuilder(PostgreSQL).SetParamPrefix("").SetParam("a", "1").MustAddParam("a"))
+		}, `SELECT * FROM t WHERE NOT ("a"=:a)`, ""},
+		{"nil and empty filters", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").
+				ApplyFilterNot(nil).ApplyFilterNot(newTestBuilder(PostgreSQL))
+		}, `SELECT * FROM t`, ""},
+	})
+}
+
+func TestPostgresJsonb( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"contains", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("f", `{"a":1}`).AddJsonbContains("data", "f")
+		}, `SELECT * FROM t WHERE "data" @> CAST(:f AS jsonb)`, ""},
+		{"contains needs postgres", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").AddJsonbContains("data", "f")
+		}, `SELECT * FROM t`, "not supported"},
+		{"path", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParam("c", "Oslo").AddParamJsonPath("data", "address.city", "c")
+		}, `SELECT * FROM t WHERE "data"#>>'{address,city}'=:c`, ""},
+	})
+}
+
+func TestAddJsonExtractParam( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"mysql", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetTypedParam("n", 5).AddJsonExtractParam("data", "a.n", "n")
+		}, "SELECT * FROM t WHERE JSON_EXTRACT(`data`, '$.a.n')=:n", ""},
+		{"needs mysql", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddJsonExtractParam("data", "a", "n")
+		}, `SELECT * FROM t`, "not supported"},
+	})
+}
+
+func TestValidateIdentifier( t *testing.T ) {
+	tests := []struct {
+		name    string
+		driver  DriverName
+		ident   string
+		wantErr bool
+	}{
+		{"empty", PostgreSQL, "", true},
+		{"NUL", PostgreSQL, "a\x00b", true},
+		{"postgres 63 bytes", PostgreSQL, strings.Repeat("a", 63), false},
+		{"postgres 64 bytes", PostgreSQL, strings.Repeat("a", 64), true},
+		{"mysql 64 chars", MySQL, strings.Repeat("é", 64), false},
+		{"mysql 65 chars", MySQL, strings.Repeat("a", 65), true},
+		{"mysql trailing space", MySQL, "name ", true},
+		{"mysql outside the BMP", MySQL, "emoji\U0001F600", true},
+		{"sql server 128 chars", MSSQL, strings.Repeat("a", 128), false},
+		{"sql server 129 chars", MSSQL, strings.Repeat("a", 129), true},
+		{"reserved word", SQLite, "order", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if err := newTestBuilder(tt.driver).ValidateIdentifier(tt.ident); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithRecursiveCTE( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"recursive CTE", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM tree").WithRecursiveCTE("tree",
+				newTestBuilder(PostgreSQL).StartWith("SELECT id FROM n WHERE id = :root").SetParam("root", "1"),
+				newTestBuilder(PostgreSQL).StartWith("SELECT n.id FROM n JOIN tree ON n.pid = tree.id"))
+		}, `WITH RECURSIVE "tree" AS (SELECT id FROM n WHERE id = :root UNION ALL ` +
+			`SELECT n.id FROM n JOIN tree ON n.pid = tree.id) SELECT * FROM tree`, ""},
+		{"second recursive CTE", func() *Builder {
+			theAnchor := newTestBuilder(PostgreSQL).StartWith("SELECT 1")
+			theRecursive := newTestBuilder(PostgreSQL).StartWith("SELECT 2")
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM a").
+				WithRecursiveCTE("a", theAnchor, theRecursive).WithRecursiveCTE("b", theAnchor, theRecursive)
+		}, `WITH RECURSIVE "b" AS (SELECT 1 UNION ALL SELECT 2), "a" AS (SELECT 1 UNION ALL SELECT 2) SELECT * FROM a`, ""},
+		{"needs both parts", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT 1").WithRecursiveCTE("x", nil, nil)
+		}, `SELECT 1`, "requires both"},
+	})
+}
+
+func TestAddWindowField( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"window", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT").AddWindowField(WindowFunction{
+				Func: "ROW_NUMBER()", PartitionBy: []string{"t.a"}, OrderBy: []string{"b desc", "c"}, Alias: "rn",
+			})
+		}, `SELECT ROW_NUMBER() OVER (PARTITION BY "t"."a" ORDER BY "b" DESC, "c" ASC) AS "rn"`, ""},
+	})
+}
+
+func TestAssertArgsMatch( t *testing.T ) {
+	tests := []struct {
+		name    string
+		build   func() *Builder
+		wantErr string
+	}{
+		{"unbound param", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE a = :a")
+		}, "never set"},
+		{"hand written placeholder", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE b = $2 AND a = :a").SetParam("a", "1")
+		}, "2 placeholders but there are 1 args"},
+		{"hand written question mark", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t WHERE b = ? AND a = :a").SetParam("a", "1")
+		}, "2 placeholders but there are 1 args"},
+		{"jsonb operators are not placeholders", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t WHERE d ? 'k' AND d ?| array['a'] AND d ?& array['b'] AND a = :a").
+				SetParam("a", "1")
+		}, ""},
+		{"named params", func() *Builder {
+			return newTestBuilder(MSSQL).StartWith("SELECT * FROM t WHERE a = :a AND b = ?").SetParam("a", "1")
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			var theLogged int
+			b := tt.build().SetLogger(func( aSql string, aArgs []interface{} ) { theLogged += 1 })
+			err := b.AssertArgsMatch()
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+			if theLogged != 0 {
+				t.Errorf("AssertArgsMatch() fired the logger %d times", theLogged)
+			}
+		})
+	}
+}
+
+func TestGetQuoted( t *testing.T ) {
+	tests := []struct {
+		name   string
+		driver DriverName
+		ident  string
+		want   string
+	}{
+		{"mysql", MySQL, "order", "`order`"},
+		{"postgres", PostgreSQL, "order", `"order"`},
+		{"delimiter is doubled", PostgreSQL, `a"b`, `"a""b"`},
+		{"mysql delimiter is doubled", MySQL, "a`b", "`a``b`"},
+		{"NUL is stripped", SQLite, "a\x00b", `"ab"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if got := newTestBuilder(tt.driver).GetQuoted(tt.ident); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamNamespaces( t *testing.T ) {
+	newFilter := func() *Builder {
+		return newTestBuilder(PostgreSQL).SetParamPrefix("").SetParam("id", "1").MustAddParam("id")
+	}
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+		ApplyFilter(newFilter().WithParamNamespace("f1_")).And().
+		ApplyFilter(newFilter().WithParamNamespace("f2_"))
+	if got, want := b.GetSQLStatement(), `SELECT * FROM t WHERE "id"=:f1_id AND "id"=:f2_id`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if b.GetParam("f1_id") == nil || b.GetParam("f2_id") == nil || b.GetParam("id") != nil {
+		t.Errorf("got params %v", b.SQLparams())
+	}
+	b = newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause()
+	theSub := b.NewSubBuilder().StartWith("SELECT id FROM u").StartWhereClause().SetParam("id", "2").MustAddParam("id")
+	b.AddSubQueryForColumn(theSub, "id")
+	if got, want := b.GetSQLStatement(), `SELECT * FROM t WHERE "id" IN (SELECT id FROM u WHERE "id"=:sq1_id)`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSQLnamedArgs( t *testing.T ) {
+	tests := []struct {
+		name   string
+		driver DriverName
+		build  func( b *Builder ) *Builder
+		want   map[string]interface{}
+	}{
+		{"mysql expands sets", MySQL, func( b *Builder ) *Builder {
+			return b.MustAddParam("a").And().MustAddParam("n").And().MustAddParam("s")
+		}, map[string]interface{}{"a": nil, "n": 5, "s_1": "x", "s_2": "y"}},
+		{"postgres IN list", PostgreSQL, func( b *Builder ) *Builder {
+			return b.MustAddParam("s")
+		}, map[string]interface{}{"s_1": "x", "s_2": "y"}},
+		{"postgres array", PostgreSQL, func( b *Builder ) *Builder {
+			return b.MustAddParam("n").And().AddParamAsArray("s", "s")
+		}, map[string]interface{}{"n": 5, "s": `{"x","y"}`}},
+		{"unused params", PostgreSQL, func( b *Builder ) *Builder {
+			return b
+		}, map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			b := newNamedTestBuilder(tt.driver).StartWith("UPDATE t SET x=1").SetParamPrefix(" WHERE ").
+				SetNullableParam("a", nil).SetTypedParam("n", 5).SetParamSet("s", &[]string{"x", "y"})
+			if got := tt.build(b).SQLnamedArgs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddParamsFromStruct( t *testing.T ) {
+	type Paging struct {
+		Owner *string `db:"owner_id"`
+	}
+	type Filter struct {
+		Paging
+		Status *string
+		Ids    *[]string `db:"id"`
+		Age    *int
+		Skip   *string `db:"-"`
+		Unset  *string
+	}
+	theStatus, theOwner, theAge := "active", "7", 30
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+		AddParamsFromStruct(&Filter{Paging: Paging{Owner: &theOwner}, Status: &theStatus,
+			Ids: &[]string{"1", "2"}, Age: &theAge, Skip: &theStatus})
+	theWant := `SELECT * FROM t WHERE "owner_id"=:owner_id AND "status"=:status AND "id" IN (:id_1,:id_2) AND "age"=:age`
+	if got := b.GetSQLStatement(); got != theWant {
+		t.Errorf("got %q, want %q", got, theWant)
+	}
+	if got := b.SQLargs(); !reflect.DeepEqual(got, []interface{}{"7", "active", "1", "2", 30}) {
+		t.Errorf("got args %v", got)
+	}
+}
+
+func TestSQLargsFollowChanges( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+		SetParam("a", "1").MustAddParam("a")
+	b.SQL()
+	b.And().SetParam("b", "2").MustAddParam("b")
+	if got := b.SQLargs(); !reflect.DeepEqual(got, []interface{}{"1", "2"}) {
+		t.Errorf("got args %v after adding a param", got)
+	}
+	b.SetParam("a", "3")
+	if got := b.SQLargs(); !reflect.DeepEqual(got, []interface{}{"3", "2"}) {
+		t.Errorf("got args %v after changing a param", got)
+	}
+	if got, want := b.SQL(), `SELECT * FROM t WHERE "a"=$1 AND "b"=$2`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAddSubQueryComparison( t *testing.T ) {
+	newSub := func() *Builder {
+		return newTestBuilder(PostgreSQL).StartWith("SELECT uid FROM o").StartWhereClause().
+			SetParam("id", "2").MustAddParam("id")
+	}
+	runSqlTests(t, []sqlTest{
+		{"quantified", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+				AddSubQueryComparison("n", ">", "all", newSub())
+		}, `SELECT * FROM t WHERE "n" > ALL (SELECT uid FROM o WHERE "id"=:id)`, ""},
+		{"not supported by sqlite", func() *Builder {
+			return newTestBuilder(SQLite).StartWith("SELECT * FROM t").AddSubQueryComparison("n", ">", "ANY", newSub())
+		}, `SELECT * FROM t`, "not supported"},
+		{"bad quantifier", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddSubQueryComparison("n", ">", "EVERY", newSub())
+		}, `SELECT * FROM t`, "unknown sub-query quantifier"},
+	})
+}
+
+func TestAddColumnCompare( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"columns", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM a, b").StartWhereClause().
+				AddColumnCompare("a.id", "=", "b.a_id")
+		}, `SELECT * FROM a, b WHERE "a"."id" = "b"."a_id"`, ""},
+		{"bad operator", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM a").AddColumnCompare("a", "; DROP", "b")
+		}, `SELECT * FROM a`, "cannot be used to compare columns"},
+	})
+}
+
+func TestGetParamKeysInSQLOrder( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT x::int FROM t WHERE c = :c AND a = :a AND ':q' <> :c")
+	if got := b.GetParamKeysInSQLOrder(false); !reflect.DeepEqual(got, []string{"c", "a", "c"}) {
+		t.Errorf("got keys %v", got)
+	}
+	if got := b.GetParamKeysInSQLOrder(true); !reflect.DeepEqual(got, []string{"c", "a"}) {
+		t.Errorf("got distinct keys %v", got)
+	}
+}
+
+func TestAddParamEmptyAsNull( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"empty", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetDataSource(MapDataSource{"a": {""}}).
+				StartWith("SELECT * FROM t").StartWhereClause().AddParamEmptyAsNull("col", "a")
+		}, `SELECT * FROM t WHERE "col" IS NULL`, ""},
+		{"keeps values", func() *Builder {
+			return newTestBuilder(PostgreSQL).SetDataSource(MapDataSource{"a": {"x"}}).
+				StartWith("SELECT * FROM t").StartWhereClause().AddParamEmptyAsNull("col", "a")
+		}, `SELECT * FROM t WHERE "col"=:a`, ""},
+	})
+}
+
+func TestAddDistinctOn( t *testing.T ) {
+	runSqlTests(t, []sqlTest{
+		{"leads the order by", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddDistinctOn("a").
+				ApplyOrderBySequence(OrderBySequence{{"a", "DESC"}, {"b", "DESC"}})
+		}, `SELECT DISTINCT ON ("a") * FROM t ORDER BY "a" DESC,"b" DESC`, ""},
+		{"missing from the order by", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddDistinctOn("a").
+				ApplyOrderBySequence(OrderBySequence{{"b", "DESC"}})
+		}, `SELECT DISTINCT ON ("a") * FROM t`, `column "a" must be ORDER BY field 1`},
+		{"not leading the order by", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddDistinctOn("a").
+				ApplyOrderBySequence(OrderBySequence{{"b", "DESC"}, {"a", "ASC"}})
+		}, `SELECT DISTINCT ON ("a") * FROM t`, `column "a" must be ORDER BY field 1`},
+		{"out of order", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddDistinctOn("a", "b").
+				ApplyOrderBySequence(OrderBySequence{{"b", "ASC"}, {"a", "ASC"}, {"c", "DESC"}})
+		}, `SELECT DISTINCT ON ("a", "b") * FROM t`, `column "a" must be ORDER BY field 1`},
+		{"shorter order by", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddDistinctOn("a", "b").
+				ApplyOrderBySequence(OrderBySequence{{"a", "ASC"}})
+		}, `SELECT DISTINCT ON ("a", "b") * FROM t`, `column "b" must be ORDER BY field 2`},
+		{"without an order by", func() *Builder {
+			return newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").AddDistinctOn("a").
+				ApplyOrderBySequence(nil)
+		}, `SELECT DISTINCT ON ("a") * FROM t`, ""},
+		{"needs postgres", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").AddDistinctOn("a")
+		}, `SELECT * FROM t`, "not supported"},
+	})
+}
+
+func TestMySQLPlaceholders( t *testing.T ) {
+	runArgsTests(t, []argsTest{
+		{"repeats args", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t WHERE a = :x OR b = :y OR c = :x").
+				SetParam("x", "1").SetParam("y", "2")
+		}, `SELECT * FROM t WHERE a = ? OR b = ? OR c = ?`, []interface{}{"1", "2", "1"}},
+		{"sets", func() *Builder {
+			return newTestBuilder(MySQL).StartWith("SELECT * FROM t").StartWhereClause().
+				SetParamSet("id", &[]string{"1", "2"}).MustAddParam("id")
+		}, "SELECT * FROM t WHERE `id` IN (?,?)", []interface{}{"1", "2"}},
+	})
+	b := newNamedTestBuilder(MySQL).StartWith("SELECT * FROM t WHERE a = :a AND b = :b").
+		SetParam("a", "1").SetParam("b", "2")
+	theSql, theArgs := b.getSqlAndArgs()
+	var theNames []string
+	for _, theArg := range theArgs {
+		theNames = append(theNames, theArg.(sql.NamedArg).Name)
+	}
+	sort.Strings(theNames)
+	if theSql != "SELECT * FROM t WHERE a = :a AND b = :b" || !reflect.DeepEqual(theNames, []string{"a", "b"}) {
+		t.Errorf("a named driver got %q with args %v", theSql, theArgs)
+	}
+}
@@ -8,11 +8,26 @@ const SQLSTATE_NO_DATA string = "02000"
 // SQLSTATE_TABLE_DOES_NOT_EXIST 5 digit ANSI SQL code meaning a table referenced
 // in the SQL does not exist.
 const SQLSTATE_TABLE_DOES_NOT_EXIST string = "42S02"
+// SQLSTATE_UNDEFINED_TABLE 5 digit PostgreSQL code meaning a table referenced
+// in the SQL does not exist.
+const SQLSTATE_UNDEFINED_TABLE string = "42P01"
+// SQLSTATE_SERIALIZATION_FAILURE 5 digit ANSI SQL code meaning the transaction
+// was rolled back due to a serialization failure (or deadlock for MySQL).
+const SQLSTATE_SERIALIZATION_FAILURE string = "40001"
+// SQLSTATE_DEADLOCK_DETECTED 5 digit PostgreSQL code meaning the transaction was
+// rolled back due to a deadlock.
+const SQLSTATE_DEADLOCK_DETECTED string = "40P01"
 
 // ORDER_BY_ASCENDING The SQL element meaning ascending order when sorting.
 const ORDER_BY_ASCENDING string = "ASC"
 // ORDER_BY_DESCENDING The SQL element meaning descending order when sorting.
 const ORDER_BY_DESCENDING string = "DESC"
+// ORDER_BY_NULLS_FIRST The SQL element placing NULLs ahead of other values when
+// sorting; may follow the sort direction in an OrderByList value: "DESC NULLS FIRST".
+const ORDER_BY_NULLS_FIRST string = "NULLS FIRST"
+// ORDER_BY_NULLS_LAST The SQL element placing NULLs after other values when
+// sorting; may follow the sort direction in an OrderByList value: "ASC NULLS LAST".
+const ORDER_BY_NULLS_LAST string = "NULLS LAST"
 // FIELD_LIST_HINT_START Sometimes we have a nested query in field list.
 // So in order for sql.Builder::getQueryTotals() to work automatically,
 // we need to supply a comment hint to start the field list.
@@ -23,3 +38,7 @@ const FIELD_LIST_HINT_START string = `/* FIELDLIST */`
 const FIELD_LIST_HINT_END string = `/* /FIELDLIST */`
 // OPERATOR_NOT_EQUAL Standard SQL specifies '<>' as NOT EQUAL.
 const OPERATOR_NOT_EQUAL string = "<>"
+// OPERATOR_LIKE Standard SQL pattern matching operator.
+const OPERATOR_LIKE string = "LIKE"
+// OPERATOR_NOT_LIKE Standard SQL negated pattern matching operator.
+const OPERATOR_NOT_LIKE string = "NOT LIKE"
@@ -0,0 +1,155 @@
+package sqlBits
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// MapDataSource IDataSource for a map of keys to value lists, such as HTTP headers.
+// A key with a single value is not considered a list; one with several is.
+type MapDataSource map[string][]string
+
+// IsKeyDefined Returns TRUE if the key exists, even if it has no values.
+func (ds MapDataSource) IsKeyDefined( aKey string ) bool {
+	_, ok := ds[aKey]
+	return ok
+}
+
+// IsKeyValueAList Returns TRUE if the key has more than one value.
+func (ds MapDataSource) IsKeyValueAList( aKey string ) bool {
+	return len(ds[aKey]) > 1
+}
+
+// GetValueForKey Returns the first value of the key, or nil if it has none.
+func (ds MapDataSource) GetValueForKey( aKey string ) *string {
+	if theValues := ds[aKey]; len(theValues) > 0 {
+		theValue := theValues[0]
+		return &theValue
+	}
+	return nil
+}
+
+// GetValueListForKey Returns a copy of the values of the key, or nil if undefined.
+func (ds MapDataSource) GetValueListForKey( aKey string ) *[]string {
+	if theValues, ok := ds[aKey]; ok {
+		theList := make([]string, len(theValues))
+		copy(theList, theValues)
+		return &theList
+	}
+	return nil
+}
+
+// URLValuesDataSource IDataSource for HTTP form or query values,
+// e.g. URLValuesDataSource(aRequest.Form).
+type URLValuesDataSource url.Values
+
+// IsKeyDefined Returns TRUE if the key exists, even if it has no values.
+func (ds URLValuesDataSource) IsKeyDefined( aKey string ) bool {
+	return MapDataSource(ds).IsKeyDefined(aKey)
+}
+
+// IsKeyValueAList Returns TRUE if the key has more than one value.
+func (ds URLValuesDataSource) IsKeyValueAList( aKey string ) bool {
+	return MapDataSource(ds).IsKeyValueAList(aKey)
+}
+
+// GetValueForKey Returns the first value of the key, or nil if it has none.
+func (ds URLValuesDataSource) GetValueForKey( aKey string ) *string {
+	return MapDataSource(ds).GetValueForKey(aKey)
+}
+
+// GetValueListForKey Returns a copy of the values of the key, or nil if undefined.
+func (ds URLValuesDataSource) GetValueListForKey( aKey string ) *[]string {
+	return MapDataSource(ds).GetValueListForKey(aKey)
+}
+
+// INullableListDataSource Optionally implemented by an IDataSource whose value
+// lists may contain NULL, which a list of strings cannot hold. The Builder then
+// binds the non-NULL values as the param set and matches NULL explicitly, e.g.
+// `("col" IN (:key_1) OR "col" IS NULL)`.
+type INullableListDataSource interface {
+	IsNullInKeyValueList( aKey string ) bool
+}
+
+// JSONDataSource IDataSource for a decoded JSON object, such as a request body
+// unmarshaled into a map[string]interface{}. Scalars are converted to strings,
+// JSON null is a nil value, arrays are lists, and nested objects are returned as
+// their JSON encoding. An array containing null lists only its non-null elements,
+// see INullableListDataSource.
+type JSONDataSource map[string]interface{}
+
+// IsNullInKeyValueList Returns TRUE if the key's value is a JSON array with a
+// null element.
+func (ds JSONDataSource) IsNullInKeyValueList( aKey string ) bool {
+	if theArray, ok := ds[aKey].([]interface{}); ok {
+		for _, theElem := range theArray {
+			if theElem == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonValueToString Converts a decoded JSON value into its string form.
+func jsonValueToString( aValue interface{} ) string {
+	switch v := aValue.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]interface{}, []interface{}:
+		theJson, _ := json.Marshal(v)
+		return string(theJson)
+	default:
+		return fmt.Sprint(v)
+	}//switch
+}
+
+// IsKeyDefined Returns TRUE if the key exists, even if its value is null.
+func (ds JSONDataSource) IsKeyDefined( aKey string ) bool {
+	_, ok := ds[aKey]
+	return ok
+}
+
+// IsKeyValueAList Returns TRUE if the key's value is a JSON array.
+func (ds JSONDataSource) IsKeyValueAList( aKey string ) bool {
+	_, ok := ds[aKey].([]interface{})
+	return ok
+}
+
+// GetValueForKey Returns the string form of the key's value, or nil if it is
+// null or undefined.
+func (ds JSONDataSource) GetValueForKey( aKey string ) *string {
+	if theValue := ds[aKey]; theValue != nil {
+		s := jsonValueToString(theValue)
+		return &s
+	}
+	return nil
+}
+
+// GetValueListForKey Returns the string forms of the key's non-null array
+// elements, or a single element list for a non-array value; nil if it is null
+// or undefined.
+func (ds JSONDataSource) GetValueListForKey( aKey string ) *[]string {
+	theValue := ds[aKey]
+	if theValue == nil {
+		return nil
+	}
+	if theArray, ok := theValue.([]interface{}); ok {
+		theList := make([]string, 0, len(theArray))
+		for _, theElem := range theArray {
+			if theElem != nil {
+				theList = append(theList, jsonValueToString(theElem))
+			}
+		}
+		return &theList
+	}
+	return &[]string{jsonValueToString(theValue)}
+}
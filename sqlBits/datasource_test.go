@@ -0,0 +1,113 @@
+package sqlBits
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// dataSourceTest The expected view of a key in a data source, see runDataSourceTests().
+type dataSourceTest struct {
+	name      string
+	key       string
+	defined   bool
+	isList    bool
+	wantValue interface{}
+	wantList  interface{}
+}
+
+// runDataSourceTests Checks each key of the tests against the data source.
+func runDataSourceTests( t *testing.T, aSource IDataSource, aTests []dataSourceTest ) {
+	t.Helper()
+	for _, tt := range aTests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if got := aSource.IsKeyDefined(tt.key); got != tt.defined {
+				t.Errorf("IsKeyDefined() got %v", got)
+			}
+			if got := aSource.IsKeyValueAList(tt.key); got != tt.isList {
+				t.Errorf("IsKeyValueAList() got %v", got)
+			}
+			var theValue interface{}
+			if v := aSource.GetValueForKey(tt.key); v != nil {
+				theValue = *v
+			}
+			if theValue != tt.wantValue {
+				t.Errorf("GetValueForKey() got %v, want %v", theValue, tt.wantValue)
+			}
+			var theList interface{}
+			if v := aSource.GetValueListForKey(tt.key); v != nil {
+				theList = *v
+			}
+			if !reflect.DeepEqual(theList, tt.wantList) {
+				t.Errorf("GetValueListForKey() got %v, want %v", theList, tt.wantList)
+			}
+		})
+	}
+}
+
+func TestMapDataSources( t *testing.T ) {
+	tests := []dataSourceTest{
+		{"value", "s", true, false, "x", []string{"x"}},
+		{"list", "l", true, true, "a", []string{"a", "2"}},
+		{"undefined", "q", false, false, nil, nil},
+	}
+	t.Run("map", func( t *testing.T ) {
+		runDataSourceTests(t, MapDataSource{"s": {"x"}, "l": {"a", "2"}}, tests)
+	})
+	t.Run("url", func( t *testing.T ) {
+		runDataSourceTests(t, URLValuesDataSource(url.Values{"s": {"x"}, "l": {"a", "2"}}), tests)
+	})
+}
+
+func TestJSONDataSource( t *testing.T ) {
+	var theJson JSONDataSource
+	if err := json.Unmarshal([]byte(`{"s":"x","n":1.5,"i":10,"b":true,"z":null,
+		"l":["a",2],"lz":["a",null],"o":{"k":"v"}}`), &theJson); err != nil {
+		t.Fatal(err)
+	}
+	runDataSourceTests(t, theJson, []dataSourceTest{
+		{"string", "s", true, false, "x", []string{"x"}},
+		{"float", "n", true, false, "1.5", []string{"1.5"}},
+		{"integer", "i", true, false, "10", []string{"10"}},
+		{"bool", "b", true, false, "true", []string{"true"}},
+		{"null", "z", true, false, nil, nil},
+		{"list", "l", true, true, `["a",2]`, []string{"a", "2"}},
+		{"list with null", "lz", true, true, `["a",null]`, []string{"a"}},
+		{"object", "o", true, false, `{"k":"v"}`, []string{`{"k":"v"}`}},
+		{"undefined", "q", false, false, nil, nil},
+	})
+}
+
+func TestJSONDataSourceParams( t *testing.T ) {
+	var theJson JSONDataSource
+	if err := json.Unmarshal([]byte(`{"a":null,"ids":["1","2"],"nids":["1",null],"onlynull":[null]}`), &theJson); err != nil {
+		t.Fatal(err)
+	}
+	if theJson.IsNullInKeyValueList("ids") || !theJson.IsNullInKeyValueList("nids") {
+		t.Error("IsNullInKeyValueList() got the wrong lists")
+	}
+	newBuilder := func() *Builder {
+		return newTestBuilder(PostgreSQL).SetDataSource(theJson).StartWith("SELECT * FROM t").StartWhereClause()
+	}
+	runSqlTests(t, []sqlTest{
+		{"null and list", func() *Builder {
+			return newBuilder().AddParamIfDefined("a").And().AddParamIfDefined("ids")
+		}, `SELECT * FROM t WHERE "a" IS NULL AND "ids" IN (:ids_1,:ids_2)`, ""},
+		{"list with null", func() *Builder {
+			return newBuilder().AddParamIfDefined("nids")
+		}, `SELECT * FROM t WHERE ("nids" IN (:nids_1) OR "nids" IS NULL)`, ""},
+		{"list with null for a column", func() *Builder {
+			return newBuilder().SetParamOperator("<>").AddParamForColumnIfDefined("nids", "id")
+		}, `SELECT * FROM t WHERE ("id" NOT IN (:nids_1) AND "id" IS NOT NULL)`, ""},
+		{"list of only null", func() *Builder {
+			return newBuilder().MustAddParam("onlynull")
+		}, `SELECT * FROM t WHERE "onlynull" IS NULL`, ""},
+		{"chunked list with null", func() *Builder {
+			return newBuilder().SetMaxInListSize(1).MustAddParam("nids")
+		}, `SELECT * FROM t WHERE ("nids" IN (:nids_1) OR "nids" IS NULL)`, ""},
+		{"operator that cannot match null", func() *Builder {
+			return newBuilder().SetParamOperator("LIKE").MustAddParam("nids")
+		}, `SELECT * FROM t`, "cannot match the NULL"},
+	})
+}
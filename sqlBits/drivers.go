@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"reflect"
+	"sync"
 )
 
 type DriverName string
@@ -12,6 +13,7 @@ const (
 	MySQL DriverName = "MySQL"
 	PostgreSQL DriverName = "PostgreSQL"
 	SQLite DriverName = "SQLite3"
+	MSSQL DriverName = "SQLServer"
 )
 
 type DriverInfo struct {
@@ -24,37 +26,121 @@ type DriverInfo struct {
 	IdentifierDelimiter rune
 	// Not all drivers support named parameters; otherwise restricted to "$1" or "?".
 	SupportsNamedParams bool
+	// The rune prefixing named parameters, e.g. '@' for SQL Server; 0 means ':'.
+	// A Builder always uses ':' internally and converts its params to this sigil
+	// only in the SQL it hands out, e.g. SQL().
+	ParamSigil rune
+	// SQLite only: TRUE if the JSON1 extension is available, built in since 3.38.0.
+	// Assumed by SetDriverName(); clear it for older builds lacking the extension.
+	SupportsJSON1 bool
+	// The most params a single statement may bind; 0 means no known limit.
+	// SQLite allows 999 before 3.32.0 and 32766 since; SetDriverName() assumes
+	// the older limit, raise it for newer builds.
+	MaxBindParams int
 }
 
-var DriverMeta map[reflect.Type]*DriverInfo
+// DriverMeta Driver info registered by driver type. Drivers registered with
+// database/sql are added on demand the first time an unknown driver is looked up.
+// Use RegisterDriverInfo()/GetDriverMeta() rather than accessing it directly as
+// it is guarded by driverMetaLock.
+var DriverMeta = map[reflect.Type]*DriverInfo{}
+// driverMetaLock Guards all reads and writes of DriverMeta.
+var driverMetaLock sync.RWMutex
 
+// probedDriverNames The database/sql driver names already checked for DriverMeta.
+var probedDriverNames = map[string]bool{}
+// driverProbeLock Guards probing the database/sql drivers.
+var driverProbeLock sync.Mutex
+
+// SetDriverName Sets the dialect of the driver along with the dialect specific
+// settings, resetting any left over from a prior dialect.
 func (d *DriverInfo) SetDriverName( driverName string ) *DriverInfo {
 	d.Name = DriverName(driverName)
+	d.IdentifierDelimiter = 0
+	d.SupportsNamedParams = false
+	d.ParamSigil = 0
+	d.SupportsJSON1 = false
+	d.MaxBindParams = 0
 	switch d.Name {
 	case MySQL:
 		d.IdentifierDelimiter = '`'
+		d.MaxBindParams = 65535
 	case PostgreSQL:
 		d.IdentifierDelimiter = '"'
+		d.MaxBindParams = 65535
 	case SQLite:
 		d.IdentifierDelimiter = '"'
+		d.SupportsJSON1 = true
+		d.MaxBindParams = 999
+	case MSSQL:
+		d.IdentifierDelimiter = '"'
+		d.SupportsNamedParams = true
+		d.ParamSigil = '@'
+		d.MaxBindParams = 2100
 	}
 	return d
 }
 
+// ForceName Pin the dialect of the driver, e.g. for an exotic driver whose
+// registered name does not match one of our DriverName consts.
+func (d *DriverInfo) ForceName( aDriverName DriverName ) *DriverInfo {
+	return d.SetDriverName(string(aDriverName))
+}
+
+// OverrideDriverName Pin the dialect used for a driver, registering it if it is
+// not already known, so that users of exotic drivers get proper quoting, etc.
+func OverrideDriverName( dbDriver interface{}, aDriverName DriverName ) *DriverInfo {
+	driverType := reflect.TypeOf(dbDriver)
+	driverMetaLock.Lock()
+	defer driverMetaLock.Unlock()
+	if driverInfo, found := DriverMeta[driverType]; found {
+		return driverInfo.ForceName(aDriverName)
+	}
+	driverInfo := (&DriverInfo{Type: driverType}).SetDriverName(string(aDriverName))
+	DriverMeta[driverType] = driverInfo
+	return driverInfo
+}
+
 func RegisterDriverInfo( driverName string, dbDriver interface{} ) {
 	driverType := reflect.TypeOf(dbDriver)
-	DriverMeta[driverType] = (&DriverInfo{Type: driverType}).SetDriverName(driverName)
+	driverInfo := (&DriverInfo{Type: driverType}).SetDriverName(driverName)
+	driverMetaLock.Lock()
+	DriverMeta[driverType] = driverInfo
+	driverMetaLock.Unlock()
+}
+
+// lookupDriverMeta Returns the driver info registered for the driver type, if any.
+func lookupDriverMeta( aDriverType reflect.Type ) (*DriverInfo, bool) {
+	driverMetaLock.RLock()
+	defer driverMetaLock.RUnlock()
+	driverInfo, found := DriverMeta[aDriverType]
+	return driverInfo, found
 }
 
-func init() {
-	DriverMeta = map[reflect.Type]*DriverInfo{}
+// probeRegisteredDrivers Register the driver info of any database/sql drivers
+// that have not been checked yet; returns TRUE if any were newly registered.
+// This is done lazily rather than at import time so that only apps looking up an
+// unknown driver pay for it; sql.Open() does not connect, it only resolves the driver.
+func probeRegisteredDrivers() bool {
+	driverProbeLock.Lock()
+	defer driverProbeLock.Unlock()
+	bRegistered := false
 	for _, driverName := range sql.Drivers() {
+		if probedDriverNames[driverName] {
+			continue
+		}
+		probedDriverNames[driverName] = true
 		// Tested empty string DSN with MySQL, PostgreSQL, and SQLite3 drivers.
 		db, _ := sql.Open(driverName, "")
 		if db != nil {
-			RegisterDriverInfo(driverName, db.Driver())
+			if _, found := lookupDriverMeta(reflect.TypeOf(db.Driver())); !found {
+				RegisterDriverInfo(driverName, db.Driver())
+				bRegistered = true
+			}
+			_ = db.Close()
 		}
 	}
+	return bRegistered
 }
 
 // SqlDriverToDriverName
@@ -62,21 +148,103 @@ func init() {
 // The database/sql API doesn't provide a way to get the registry name for
 // a driver from the driver type.
 func SqlDriverToDriverName(driver driver.Driver) DriverName {
-	driverType := reflect.TypeOf(driver)
-	if driverInfo, found := DriverMeta[driverType]; found {
+	if driverInfo := GetDriverMeta(driver); driverInfo != nil {
 		return driverInfo.Name
 	}
 	return ""
 }
 
+// driverUnwrapper Instrumented drivers that wrap another driver may expose it.
+type driverUnwrapper interface {
+	Unwrap() driver.Driver
+}
+
+// driverInnerer Instrumented drivers that wrap another driver may expose it.
+type driverInnerer interface {
+	Inner() driver.Driver
+}
+
+// GetDriverMeta Returns the driver info for a driver, or nil if not known.
+// Wrapper drivers (e.g. for tracing/metrics) that are not registered themselves
+// resolve to the driver they wrap if they have an Unwrap() or Inner() method.
 func GetDriverMeta(dbDriver interface{}) *DriverInfo {
 	driverType := reflect.TypeOf(dbDriver)
-	if _, found := DriverMeta[driverType]; found {
-		return DriverMeta[driverType]
+	if driverInfo, found := lookupDriverMeta(driverType); found {
+		return driverInfo
+	}
+	switch w := dbDriver.(type) {
+	case driverUnwrapper:
+		return GetDriverMeta(w.Unwrap())
+	case driverInnerer:
+		return GetDriverMeta(w.Inner())
+	}//switch
+	if dbDriver != nil && probeRegisteredDrivers() {
+		driverInfo, _ := lookupDriverMeta(driverType)
+		return driverInfo
 	}
 	return nil
 }
 
+// RegisterDriverAlias Register a wrapper driver (e.g. for tracing/metrics) as an
+// alias of a known dialect so that it gets the same driver info. If a driver
+// with that name was already registered, its info is shared with the alias.
+func RegisterDriverAlias( aWrapperDriver interface{}, aDriverName DriverName ) {
+	theWrapperType := reflect.TypeOf(aWrapperDriver)
+	driverMetaLock.Lock()
+	defer driverMetaLock.Unlock()
+	for _, theDriverInfo := range DriverMeta {
+		if theDriverInfo.Name == aDriverName {
+			DriverMeta[theWrapperType] = theDriverInfo
+			return
+		}
+	}
+	DriverMeta[theWrapperType] = (&DriverInfo{Type: theWrapperType}).SetDriverName(string(aDriverName))
+}
+
+// GetDriverMetaFromDB Returns the driver info for the driver used by an open
+// database handle, or nil if the driver is not known.
+func GetDriverMetaFromDB( aDb *sql.DB ) *DriverInfo {
+	if aDb == nil {
+		return nil
+	}
+	return GetDriverMeta(aDb.Driver())
+}
+
+// GetDriverMetaFromConn Returns the driver info for the driver used by a single
+// database connection, or nil if the driver is not known. The database/sql API
+// does not expose the driver of a Conn, so the driver registered from the same
+// package as the underlying driver connection is used.
+func GetDriverMetaFromConn( aConn *sql.Conn ) *DriverInfo {
+	if aConn == nil {
+		return nil
+	}
+	// drivers are registered lazily, make sure all of them are known
+	probeRegisteredDrivers()
+	var theResult *DriverInfo
+	_ = aConn.Raw(func( aDriverConn interface{} ) error {
+		theConnType := reflect.TypeOf(aDriverConn)
+		for theConnType != nil && theConnType.Kind() == reflect.Ptr {
+			theConnType = theConnType.Elem()
+		}
+		if theConnType == nil || theConnType.PkgPath() == "" {
+			return nil
+		}
+		driverMetaLock.RLock()
+		defer driverMetaLock.RUnlock()
+		for theDriverType, theDriverInfo := range DriverMeta {
+			for theDriverType.Kind() == reflect.Ptr {
+				theDriverType = theDriverType.Elem()
+			}
+			if theDriverType.PkgPath() == theConnType.PkgPath() {
+				theResult = theDriverInfo
+				return nil
+			}
+		}
+		return nil
+	})
+	return theResult
+}
+
 type DbMetatater interface {
 	GetDbMeta() *DriverInfo
 }
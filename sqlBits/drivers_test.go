@@ -0,0 +1,191 @@
+package sqlBits
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// forgetDriverMeta Removes the driver info of the drivers, restoring any that
+// were registered when the returned func is called.
+func forgetDriverMeta( aDrivers ...interface{} ) func() {
+	theSaved := map[reflect.Type]*DriverInfo{}
+	driverMetaLock.Lock()
+	defer driverMetaLock.Unlock()
+	for _, theDriver := range aDrivers {
+		theType := reflect.TypeOf(theDriver)
+		if theInfo, found := DriverMeta[theType]; found {
+			theSaved[theType] = theInfo
+		}
+		delete(DriverMeta, theType)
+	}
+	return func() {
+		driverMetaLock.Lock()
+		defer driverMetaLock.Unlock()
+		for _, theDriver := range aDrivers {
+			delete(DriverMeta, reflect.TypeOf(theDriver))
+		}
+		for theType, theInfo := range theSaved {
+			DriverMeta[theType] = theInfo
+		}
+	}
+}
+
+func TestGetDriverMetaFromDB( t *testing.T ) {
+	if GetDriverMetaFromDB(nil) != nil || GetDriverMetaFromConn(nil) != nil {
+		t.Error("expected nil driver info for nil handles")
+	}
+	theDb, _ := openFakeDB(t, "sqlbits_fake_named")
+	defer theDb.Close()
+	if got := GetDriverMetaFromDB(theDb); got == nil || got.Name != MSSQL {
+		t.Errorf("got %+v", got)
+	}
+	// the fake drivers share a package, so only leave one of them registered;
+	// probe first so that the forgotten one is not registered again
+	probeRegisteredDrivers()
+	defer forgetDriverMeta(fakeNamedDriver{})()
+	theConn, err := theDb.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer theConn.Close()
+	if got := GetDriverMetaFromConn(theConn); got == nil || got.Name != SQLite {
+		t.Errorf("conn got %+v", got)
+	}
+}
+
+// testWrapDriver A tracing style driver wrapper exposing its driver with Unwrap().
+type testWrapDriver struct{ driver.Driver }
+
+func (w testWrapDriver) Unwrap() driver.Driver { return w.Driver }
+
+// testInnerDriver A driver wrapper exposing its driver with Inner().
+type testInnerDriver struct{ driver.Driver }
+
+func (w testInnerDriver) Inner() driver.Driver { return w.Driver }
+
+// testAliasDriver A wrapper driver that does not expose its driver.
+type testAliasDriver struct{ driver.Driver }
+
+func TestGetDriverMeta( t *testing.T ) {
+	defer forgetDriverMeta(testAliasDriver{}, testWrapDriver{})()
+	tests := []struct {
+		name   string
+		driver interface{}
+		want   DriverName
+	}{
+		{"registered", fakeDriver{}, SQLite},
+		{"unwrap", testWrapDriver{fakeNamedDriver{}}, MSSQL},
+		{"inner", testInnerDriver{fakeDriver{}}, SQLite},
+		{"nested wrappers", testWrapDriver{testInnerDriver{fakeNamedDriver{}}}, MSSQL},
+		{"unknown wrapper", testAliasDriver{fakeDriver{}}, ""},
+		{"nil", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			var got DriverName
+			if theInfo := GetDriverMeta(tt.driver); theInfo != nil {
+				got = theInfo.Name
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if theDriver, ok := tt.driver.(driver.Driver); ok && SqlDriverToDriverName(theDriver) != tt.want {
+				t.Errorf("SqlDriverToDriverName() got %q", SqlDriverToDriverName(theDriver))
+			}
+		})
+	}
+}
+
+func TestRegisterDriverAlias( t *testing.T ) {
+	defer forgetDriverMeta(testAliasDriver{}, testWrapDriver{})()
+	RegisterDriverAlias(testAliasDriver{}, MSSQL)
+	if got, want := GetDriverMeta(testAliasDriver{}), GetDriverMeta(fakeNamedDriver{}); got != want {
+		t.Errorf("alias got %+v, want the shared %+v", got, want)
+	}
+	RegisterDriverAlias(testWrapDriver{}, "Exotic")
+	if got := GetDriverMeta(testWrapDriver{}); got == nil || got.Name != "Exotic" {
+		t.Errorf("alias of an unregistered dialect got %+v", got)
+	}
+	// an alias wins over unwrapping
+	if got := GetDriverMeta(testWrapDriver{fakeDriver{}}); got == nil || got.Name != "Exotic" {
+		t.Errorf("registered wrapper got %+v", got)
+	}
+}
+
+func TestSetDriverName( t *testing.T ) {
+	tests := []struct {
+		name      string
+		driver    string
+		delimiter rune
+		named     bool
+		sigil     rune
+		json1     bool
+		maxParams int
+	}{
+		{"mysql", string(MySQL), '`', false, 0, false, 65535},
+		{"postgres", string(PostgreSQL), '"', false, 0, false, 65535},
+		{"sqlite", string(SQLite), '"', false, 0, true, 999},
+		{"sql server", string(MSSQL), '"', true, '@', false, 2100},
+		{"unknown", "Exotic", 0, false, 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			// start from every other dialect to be sure nothing is left over
+			for _, thePrior := range tests {
+				d := (&DriverInfo{}).SetDriverName(thePrior.driver).ForceName(DriverName(tt.driver))
+				if d.Name != DriverName(tt.driver) || d.IdentifierDelimiter != tt.delimiter ||
+					d.SupportsNamedParams != tt.named || d.ParamSigil != tt.sigil || d.SupportsJSON1 != tt.json1 ||
+					d.MaxBindParams != tt.maxParams {
+					t.Errorf("after %s got %+v", thePrior.name, d)
+				}
+			}
+		})
+	}
+}
+
+func TestOverrideDriverName( t *testing.T ) {
+	defer forgetDriverMeta(testAliasDriver{})()
+	theInfo := OverrideDriverName(testAliasDriver{}, PostgreSQL)
+	if theInfo.Name != PostgreSQL || GetDriverMeta(testAliasDriver{}) != theInfo {
+		t.Errorf("got %+v", theInfo)
+	}
+	if theInfo = OverrideDriverName(testAliasDriver{}, MySQL); theInfo.IdentifierDelimiter != '`' {
+		t.Errorf("override of a known driver got %+v", theInfo)
+	}
+}
+
+func TestDriverMetaConcurrency( t *testing.T ) {
+	const theWorkers = 16
+	theDrivers := make([]interface{}, theWorkers)
+	for i := range theDrivers {
+		// a distinct type per worker
+		theDrivers[i] = reflect.New(reflect.ArrayOf(i+1, reflect.TypeOf(byte(0)))).Elem().Interface()
+	}
+	defer forgetDriverMeta(append(theDrivers, testAliasDriver{})...)()
+	var wg sync.WaitGroup
+	for i := 0; i < theWorkers; i += 1 {
+		wg.Add(1)
+		go func( aDriver interface{} ) {
+			defer wg.Done()
+			for j := 0; j < 50; j += 1 {
+				RegisterDriverInfo(string(PostgreSQL), aDriver)
+				if theInfo := GetDriverMeta(aDriver); theInfo == nil || theInfo.Name != PostgreSQL {
+					t.Errorf("got %+v", theInfo)
+					return
+				}
+				OverrideDriverName(aDriver, MySQL)
+				RegisterDriverAlias(testAliasDriver{}, SQLite)
+				GetDriverMeta(testWrapDriver{fakeDriver{}})
+			}
+		}(theDrivers[i])
+	}
+	wg.Wait()
+	for _, theDriver := range theDrivers {
+		if theInfo := GetDriverMeta(theDriver); theInfo == nil || theInfo.Name != MySQL {
+			t.Errorf("got %+v", theInfo)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package sqlBits
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// SqlExecuter The query methods shared by *sql.DB, *sql.Tx, and *sql.Conn that
+// are needed to run a Builder's SQL statement.
+type SqlExecuter interface {
+	QueryContext( ctx context.Context, query string, args ...interface{} ) (*sql.Rows, error)
+	ExecContext( ctx context.Context, query string, args ...interface{} ) (sql.Result, error)
+}
+
+// Query Run our SQL statement, such as a SELECT, that returns rows. Named args are
+// passed if the driver supports them, otherwise ordinal args are used.
+// The caller is responsible for closing the returned rows.
+func (sqlbldr *Builder) Query( ctx context.Context, aDb SqlExecuter ) (*sql.Rows, error) {
+	theSql, theArgs := sqlbldr.getSqlAndArgs()
+	theStart := time.Now()
+	theRows, err := aDb.QueryContext(ctx, theSql, theArgs...)
+	sqlbldr.logExec(theSql, theArgs, theStart, err)
+	return theRows, err
+}
+
+// Exec Run our SQL statement, such as an INSERT/UPDATE/DELETE, without returning
+// any rows. Named args are passed if the driver supports them, otherwise ordinal
+// args are used.
+func (sqlbldr *Builder) Exec( ctx context.Context, aDb SqlExecuter ) (sql.Result, error) {
+	theSql, theArgs := sqlbldr.getSqlAndArgs()
+	theStart := time.Now()
+	theResult, err := aDb.ExecContext(ctx, theSql, theArgs...)
+	sqlbldr.logExec(theSql, theArgs, theStart, err)
+	return theResult, err
+}
+
+// logExec Report the statement run to the exec logger, if one was set.
+func (sqlbldr *Builder) logExec( aSql string, aArgs []interface{}, aStart time.Time, aErr error ) {
+	if sqlbldr.myExecLogger != nil {
+		sqlbldr.myExecLogger(aSql, aArgs, time.Since(aStart), aErr)
+	}
+}
+
+// TransactionRetryLimit The number of times RunInTransaction() retries a
+// transaction that failed with a retriable error; see IsRetriable().
+var TransactionRetryLimit = 3
+
+// RunInTransaction Runs aFunc within a transaction which is committed if aFunc
+// returns nil and rolled back otherwise. Should the transaction fail due to a
+// retriable error, such as a deadlock or serialization failure, the whole
+// transaction is run again up to TransactionRetryLimit more times, so aFunc
+// must be safe to call more than once.
+func RunInTransaction( ctx context.Context, aDb *sql.DB, aFunc func( aTx *sql.Tx ) error ) error {
+	for theAttempt := 0; ; theAttempt += 1 {
+		err := runTransaction(ctx, aDb, aFunc)
+		if err == nil || !IsRetriable(err) || theAttempt >= TransactionRetryLimit || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// runTransaction Runs aFunc within a single transaction attempt.
+func runTransaction( ctx context.Context, aDb *sql.DB, aFunc func( aTx *sql.Tx ) error ) error {
+	theTx, err := aDb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			theTx.Rollback()
+			panic(r)
+		}
+	}()
+	if err = aFunc(theTx); err != nil {
+		theTx.Rollback()
+		return err
+	}
+	return theTx.Commit()
+}
+
+// ScanRowInto Scan the current row of aRows into aDest, a pointer to a struct, by
+// matching result column names to struct fields with the same naming rules used by
+// DetermineFieldsFromTableStruct. Columns without a matching field are skipped,
+// NULL values leave non-pointer fields at their zero value, and pointer fields
+// are set to nil for NULL.
+func ScanRowInto( aRows *sql.Rows, aDest interface{} ) error {
+	theDestVal := reflect.ValueOf(aDest)
+	if aDest == nil || theDestVal.Kind() != reflect.Ptr || theDestVal.Elem().Kind() != reflect.Struct {
+		return errors.New("sqlBits: scan destination must be a pointer to a struct")
+	}
+	theDestVal = theDestVal.Elem()
+	theColumns, err := aRows.Columns()
+	if err != nil {
+		return err
+	}
+	theScanDest := make([]interface{}, len(theColumns))
+	theFields := make([]reflect.Value, len(theColumns))
+	for i, theColName := range theColumns {
+		theFields[i] = getStructFieldValueForQueryName(theDestVal, theColName)
+		if theFields[i].IsValid() {
+			// scanning into a pointer to a pointer lets NULL come back as nil
+			if theFields[i].Kind() == reflect.Ptr {
+				theScanDest[i] = theFields[i].Addr().Interface()
+			} else {
+				theScanDest[i] = reflect.New(reflect.PtrTo(theFields[i].Type())).Interface()
+			}
+		} else {
+			// unknown column, scan it and toss it
+			theScanDest[i] = new(interface{})
+		}
+	}
+	if err = aRows.Scan(theScanDest...); err != nil {
+		return err
+	}
+	for i, theField := range theFields {
+		if theField.IsValid() && theField.Kind() != reflect.Ptr {
+			if thePtr := reflect.ValueOf(theScanDest[i]).Elem(); thePtr.IsNil() {
+				theField.Set(reflect.Zero(theField.Type()))
+			} else {
+				theField.Set(thePtr.Elem())
+			}
+		}
+	}
+	return nil
+}
+
+// getStructFieldValueForQueryName Returns the settable field of the struct value
+// that maps to the query field name, allocating any nil embedded struct pointers
+// along the way; returns the zero Value if no field matches.
+func getStructFieldValueForQueryName( aStructVal reflect.Value, aFieldName string ) reflect.Value {
+	theStructType := aStructVal.Type()
+	for i:=0; i<theStructType.NumField(); i++ {
+		theField := theStructType.Field(i)
+		if !IsStructFieldExported(theField) {
+			continue
+		}
+		if theNestedType := getNestedStructType(theField); theNestedType != nil {
+			theNestedVal := aStructVal.Field(i)
+			for theNestedVal.Kind() == reflect.Ptr {
+				if theNestedVal.IsNil() {
+					if _, found := getStructFieldForQueryName(theNestedType, aFieldName); !found {
+						break
+					}
+					theNestedVal.Set(reflect.New(theNestedVal.Type().Elem()))
+				}
+				theNestedVal = theNestedVal.Elem()
+			}
+			if theNestedVal.Kind() == reflect.Struct {
+				if theResult := getStructFieldValueForQueryName(theNestedVal, aFieldName); theResult.IsValid() {
+					return theResult
+				}
+			}
+		} else if theQueryResultName := GetQueryFieldNameOfStructField(theField); theQueryResultName != "-" &&
+			(theQueryResultName == aFieldName || theField.Name == upperFirst(aFieldName)) {
+			return aStructVal.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
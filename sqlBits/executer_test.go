@@ -0,0 +1,403 @@
+package sqlBits
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver A database/sql driver for tests that records the statements it is
+// given and answers queries with the rows scripted in its fakeDB.
+type fakeDriver struct{}
+
+// fakeNamedDriver Same as fakeDriver but registered as a named param dialect.
+type fakeNamedDriver struct{ fakeDriver }
+
+// fakeDB The state of a fake database, looked up by its DSN.
+type fakeDB struct {
+	mu         sync.Mutex
+	queries    []string
+	args       [][]driver.NamedValue
+	columns    []string
+	rows       [][]driver.Value
+	commitErrs []error
+	commits    int
+	rollbacks  int
+}
+
+var fakeDBs = map[string]*fakeDB{}
+var fakeDBsLock sync.Mutex
+var fakeDBCount int
+
+func init() {
+	sql.Register("sqlbits_fake", fakeDriver{})
+	sql.Register("sqlbits_fake_named", fakeNamedDriver{})
+	RegisterDriverInfo(string(SQLite), fakeDriver{})
+	RegisterDriverInfo(string(MSSQL), fakeNamedDriver{})
+}
+
+// openFakeDB Returns a handle to a new fake database of the driver and its state.
+func openFakeDB( t *testing.T, aDriverName string ) (*sql.DB, *fakeDB) {
+	t.Helper()
+	theFake := &fakeDB{}
+	fakeDBsLock.Lock()
+	fakeDBCount += 1
+	theDsn := "db" + strconv.Itoa(fakeDBCount)
+	fakeDBs[theDsn] = theFake
+	fakeDBsLock.Unlock()
+	theDb, err := sql.Open(aDriverName, theDsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return theDb, theFake
+}
+
+func (fakeDriver) Open( aDsn string ) (driver.Conn, error) {
+	fakeDBsLock.Lock()
+	defer fakeDBsLock.Unlock()
+	if theFake, ok := fakeDBs[aDsn]; ok {
+		return &fakeConn{db: theFake}, nil
+	}
+	return nil, errors.New("fake: unknown DSN " + aDsn)
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare( aQuery string ) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: aQuery}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return &fakeTx{db: c.db}, nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) record( aArgs []driver.NamedValue ) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.queries = append(s.db.queries, s.query)
+	s.db.args = append(s.db.args, aArgs)
+}
+func (s *fakeStmt) Exec( aArgs []driver.Value ) (driver.Result, error) {
+	return nil, errors.New("fake: use ExecContext")
+}
+func (s *fakeStmt) Query( aArgs []driver.Value ) (driver.Rows, error) {
+	return nil, errors.New("fake: use QueryContext")
+}
+func (s *fakeStmt) ExecContext( ctx context.Context, aArgs []driver.NamedValue ) (driver.Result, error) {
+	s.record(aArgs)
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) QueryContext( ctx context.Context, aArgs []driver.NamedValue ) (driver.Rows, error) {
+	s.record(aArgs)
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return &fakeRows{columns: s.db.columns, rows: s.db.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next( aDest []driver.Value ) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(aDest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (tx *fakeTx) Commit() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.commits += 1
+	if len(tx.db.commitErrs) > 0 {
+		err := tx.db.commitErrs[0]
+		tx.db.commitErrs = tx.db.commitErrs[1:]
+		return err
+	}
+	return nil
+}
+func (tx *fakeTx) Rollback() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.rollbacks += 1
+	return nil
+}
+
+// argValues Returns the values of the args, by name if named.
+func argValues( aArgs []driver.NamedValue ) ([]interface{}, map[string]interface{}) {
+	var theValues []interface{}
+	var theNamed map[string]interface{}
+	for _, theArg := range aArgs {
+		if theArg.Name != "" {
+			if theNamed == nil {
+				theNamed = map[string]interface{}{}
+			}
+			theNamed[theArg.Name] = theArg.Value
+		} else {
+			theValues = append(theValues, theArg.Value)
+		}
+	}
+	return theValues, theNamed
+}
+
+func TestExecuteAggregateInto( t *testing.T ) {
+	theDb, theFake := openFakeDB(t, "sqlbits_fake")
+	defer theDb.Close()
+	b := NewBuilder(testModel{GetDriverMetaFromDB(theDb)}).StartWith("SELECT * FROM t").StartWhereClause().
+		SetParam("a", "1").MustAddParam("a")
+	theFake.columns = []string{"rowcount"}
+	theFake.rows = [][]driver.Value{{int64(7)}}
+	theCount := TotalRowCount
+	if err := b.ExecuteAggregateInto(theDb, &theCount); err != nil {
+		t.Fatal(err)
+	}
+	if theCount.Rowcount != 7 || TotalRowCount.Rowcount != 0 {
+		t.Errorf("got %d rows", theCount.Rowcount)
+	}
+	if got, want := theFake.queries[0], `SELECT count(*) AS rowcount FROM t WHERE "a"=?`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if err := b.ExecuteAggregateInto(theDb, TotalRowCount); err == nil {
+		t.Error("expected an error for a non-pointer destination")
+	}
+	// any SqlExecuter will do, such as a transaction
+	theTx, err := theDb.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer theTx.Rollback()
+	theFake.rows = [][]driver.Value{{int64(3)}}
+	if err = b.ExecuteAggregateInto(theTx, &theCount); err != nil || theCount.Rowcount != 3 {
+		t.Errorf("in a transaction got %d rows, error %v", theCount.Rowcount, err)
+	}
+	theFake.rows = nil
+	if err = b.ExecuteAggregateInto(theTx, &theCount); err != sql.ErrNoRows {
+		t.Errorf("got error %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestScanRowInto( t *testing.T ) {
+	type Stamps struct {
+		Updated *string `db:"updated_by"`
+	}
+	type row struct {
+		ID    int64 `db:"id"`
+		Name  string
+		Note  *string
+		Skip  string `db:"-"`
+		*Stamps
+	}
+	theDb, theFake := openFakeDB(t, "sqlbits_fake")
+	defer theDb.Close()
+	theFake.columns = []string{"id", "name", "note", "extra", "updated_by"}
+	theFake.rows = [][]driver.Value{
+		{int64(1), "Ann", "hi", "x", "bob"},
+		{int64(2), nil, nil, nil, nil},
+	}
+	theRows, err := theDb.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer theRows.Close()
+	var theResults []row
+	for theRows.Next() {
+		theRow := row{Name: "stale", Skip: "kept"}
+		if err = ScanRowInto(theRows, &theRow); err != nil {
+			t.Fatal(err)
+		}
+		theResults = append(theResults, theRow)
+	}
+	if len(theResults) != 2 {
+		t.Fatalf("got %d rows", len(theResults))
+	}
+	r1, r2 := theResults[0], theResults[1]
+	if r1.ID != 1 || r1.Name != "Ann" || r1.Note == nil || *r1.Note != "hi" || r1.Skip != "kept" ||
+		r1.Stamps == nil || r1.Updated == nil || *r1.Updated != "bob" {
+		t.Errorf("got first row %+v", r1)
+	}
+	if r2.ID != 2 || r2.Name != "" || r2.Note != nil || r2.Stamps == nil || r2.Updated != nil {
+		t.Errorf("got second row %+v", r2)
+	}
+	if err = ScanRowInto(theRows, row{}); err == nil {
+		t.Error("expected an error for a non-pointer destination")
+	}
+}
+
+// testRowCounter A ResultsWithRowCounter that is not a pager.
+type testRowCounter struct{ total int64 }
+
+func (r *testRowCounter) SetTotalRowCount( aTotalRowCount int64 ) { r.total = aTotalRowCount }
+
+func TestPopulateTotalRowCount( t *testing.T ) {
+	tests := []struct {
+		name      string
+		results   ResultsWithRowCounter
+		total     func( r ResultsWithRowCounter ) int64
+		wantTotal int64
+		wantQuery bool
+	}{
+		{"pager desiring a total", &testPager{wantTotal: true},
+			func( r ResultsWithRowCounter ) int64 { return r.(*testPager).total }, 42, true},
+		{"pager not desiring a total", &testPager{total: -1},
+			func( r ResultsWithRowCounter ) int64 { return r.(*testPager).total }, -1, false},
+		{"row counter", &testRowCounter{},
+			func( r ResultsWithRowCounter ) int64 { return r.(*testRowCounter).total }, 42, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			theDb, theFake := openFakeDB(t, "sqlbits_fake")
+			defer theDb.Close()
+			theFake.columns = []string{"rowcount"}
+			theFake.rows = [][]driver.Value{{int64(42)}}
+			b := NewBuilder(testModel{GetDriverMetaFromDB(theDb)}).StartWith("SELECT a FROM t GROUP BY a")
+			if err := b.PopulateTotalRowCount(context.Background(), theDb, tt.results); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.total(tt.results); got != tt.wantTotal {
+				t.Errorf("got total %d, want %d", got, tt.wantTotal)
+			}
+			if (len(theFake.queries) > 0) != tt.wantQuery {
+				t.Errorf("ran %q", theFake.queries)
+			}
+		})
+	}
+	if err := newTestBuilder(SQLite).PopulateTotalRowCount(context.Background(), nil, nil); err == nil {
+		t.Error("expected an error for nil results")
+	}
+}
+
+func TestRunInTransaction( t *testing.T ) {
+	theRetriable := &testPgError{SQLSTATE_SERIALIZATION_FAILURE}
+	theFailure := errors.New("boom")
+	tests := []struct {
+		name          string
+		commitErrs    []error
+		funcErr       error
+		wantErr       error
+		wantCalls     int
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{"commits", nil, nil, nil, 1, 1, 0},
+		{"rolls back", nil, theFailure, theFailure, 1, 0, 1},
+		{"retries a serialization failure", []error{theRetriable}, nil, nil, 2, 2, 0},
+		{"gives up retrying", []error{theRetriable, theRetriable, theRetriable, theRetriable, theRetriable},
+			nil, theRetriable, TransactionRetryLimit + 1, TransactionRetryLimit + 1, 0},
+		{"does not retry other errors", []error{theFailure}, nil, theFailure, 1, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			theDb, theFake := openFakeDB(t, "sqlbits_fake")
+			defer theDb.Close()
+			theFake.commitErrs = tt.commitErrs
+			theCalls := 0
+			err := RunInTransaction(context.Background(), theDb, func( aTx *sql.Tx ) error {
+				theCalls += 1
+				return tt.funcErr
+			})
+			if err != tt.wantErr {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if theCalls != tt.wantCalls || theFake.commits != tt.wantCommits || theFake.rollbacks != tt.wantRollbacks {
+				t.Errorf("got %d calls, %d commits, %d rollbacks", theCalls, theFake.commits, theFake.rollbacks)
+			}
+		})
+	}
+	t.Run("rolls back a panic", func( t *testing.T ) {
+		theDb, theFake := openFakeDB(t, "sqlbits_fake")
+		defer theDb.Close()
+		defer func() {
+			if r := recover(); r != "oops" || theFake.rollbacks != 1 {
+				t.Errorf("got panic %v with %d rollbacks", r, theFake.rollbacks)
+			}
+		}()
+		RunInTransaction(context.Background(), theDb, func( aTx *sql.Tx ) error { panic("oops") })
+	})
+}
+
+func TestQueryAndExec( t *testing.T ) {
+	tests := []struct {
+		name      string
+		driver    string
+		wantSql   string
+		wantArgs  []interface{}
+		wantNamed map[string]interface{}
+	}{
+		{"positional", "sqlbits_fake", `SELECT * FROM t WHERE "a"=? AND "b" IN (?,?)`,
+			[]interface{}{"1", "x", "y"}, nil},
+		{"named", "sqlbits_fake_named", `SELECT * FROM t WHERE "a"=@a AND "b" IN (@b_1,@b_2)`,
+			nil, map[string]interface{}{"a": "1", "b_1": "x", "b_2": "y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			theDb, theFake := openFakeDB(t, tt.driver)
+			defer theDb.Close()
+			b := NewBuilder(testModel{GetDriverMetaFromDB(theDb)}).StartWith("SELECT * FROM t").StartWhereClause().SetParam("a", "1").MustAddParam("a").And().
+				SetParamSet("b", &[]string{"x", "y"}).MustAddParam("b")
+			theRows, err := b.Query(context.Background(), theDb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			theRows.Close()
+			if _, err = b.Exec(context.Background(), theDb); err != nil {
+				t.Fatal(err)
+			}
+			for i, theQuery := range theFake.queries {
+				if theQuery != tt.wantSql {
+					t.Errorf("statement %d got %q, want %q", i+1, theQuery, tt.wantSql)
+				}
+				theValues, theNamed := argValues(theFake.args[i])
+				if !reflect.DeepEqual(theValues, tt.wantArgs) || !reflect.DeepEqual(theNamed, tt.wantNamed) {
+					t.Errorf("statement %d got args %v %v", i+1, theValues, theNamed)
+				}
+			}
+			if len(theFake.queries) != 2 {
+				t.Errorf("ran %d statements", len(theFake.queries))
+			}
+		})
+	}
+}
+
+func TestSetExecLogger( t *testing.T ) {
+	theDb, _ := openFakeDB(t, "sqlbits_fake")
+	defer theDb.Close()
+	var theSql []string
+	var theArgs [][]interface{}
+	b := NewBuilder(testModel{GetDriverMetaFromDB(theDb)}).
+		SetExecLogger(func( aSql string, aArgs []interface{}, aDuration time.Duration, aErr error ) {
+			theSql = append(theSql, aSql)
+			theArgs = append(theArgs, aArgs)
+		}).
+		StartWith("SELECT * FROM t").StartWhereClause().SetParam("a", "1").MustAddParam("a")
+	theRows, err := b.Query(context.Background(), theDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theRows.Close()
+	if _, err = b.Exec(context.Background(), theDb); err != nil {
+		t.Fatal(err)
+	}
+	theWant := `SELECT * FROM t WHERE "a"=?`
+	if !reflect.DeepEqual(theSql, []string{theWant, theWant}) || !reflect.DeepEqual(theArgs[0], []interface{}{"1"}) {
+		t.Errorf("logged %q with args %v", theSql, theArgs)
+	}
+}
@@ -3,6 +3,8 @@ package sqlBits
 import (
 	"reflect"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ISqlSanitizer UI defined values like sort order, pager info, and requested
@@ -37,37 +39,71 @@ var FieldNameTag = ""
 // DefaultFieldNameStrConvFunc String-conversion func for struct field name to query field name
 var DefaultFieldNameStrConvFunc = strings.ToLower
 
+// GetQueryFieldNameOfStructField Returns the query field name to use for a struct field.
+// Checks the "sql" tag, then the "db" tag, then the FieldNameTag custom tag, and
+// finally falls back to DefaultFieldNameStrConvFunc applied to the field name.
+func GetQueryFieldNameOfStructField( aField reflect.StructField ) string {
+	// see if we have a "sql" tag to use
+	theQueryResultName := aField.Tag.Get("sql")
+	if theQueryResultName == "" {
+		// else see if we have a "db" tag to use
+		theQueryResultName = aField.Tag.Get("db")
+	}
+	if theQueryResultName == "" && FieldNameTag != "" {
+		// else see if we have a custom tag to use
+		theQueryResultName = aField.Tag.Get(FieldNameTag)
+	}
+	if theQueryResultName == "" {
+		theQueryResultName = DefaultFieldNameStrConvFunc(aField.Name)
+	}
+	return theQueryResultName
+}
+
+// indirectType Returns the type pointed to by aType, following any number of pointers.
+func indirectType( aType reflect.Type ) reflect.Type {
+	for aType != nil && aType.Kind() == reflect.Ptr {
+		aType = aType.Elem()
+	}
+	return aType
+}
+
+// getNestedStructType Returns the struct type whose fields should be traversed as
+// part of the parent's field list, or nil if aField is not such a nested struct.
+// Nested structs are either tagged with a "-" name or are anonymous (embedded)
+// without a name tag; pointers to structs are followed.
+func getNestedStructType( aField reflect.StructField ) reflect.Type {
+	theType := indirectType(aField.Type)
+	if theType.Kind() != reflect.Struct {
+		return nil
+	}
+	theQueryResultName := GetQueryFieldNameOfStructField(aField)
+	if theQueryResultName == "-" ||
+		(aField.Anonymous && theQueryResultName == DefaultFieldNameStrConvFunc(aField.Name)) {
+		return theType
+	}
+	return nil
+}
+
 // DetermineFieldsFromTableStruct Returns the array of publicly defined fields available.
+// aTableStruct may be a struct or a pointer to one.
 func DetermineFieldsFromTableStruct( aTableStruct interface{} ) []string {
+	return determineFieldsFromStructType(indirectType(reflect.TypeOf(aTableStruct)))
+}
+
+// determineFieldsFromStructType Returns the array of publicly defined fields available.
+func determineFieldsFromStructType( aStructType reflect.Type ) []string {
 	var theResult []string
-	rowVal := reflect.ValueOf(aTableStruct)
-	rowType := reflect.TypeOf(aTableStruct)
-	for i:=0; i<rowType.NumField(); i++ {
-		theField := rowType.Field(i)
+	if aStructType == nil || aStructType.Kind() != reflect.Struct {
+		return theResult
+	}
+	for i:=0; i<aStructType.NumField(); i++ {
+		theField := aStructType.Field(i)
 		if IsStructFieldExported(theField) {
-			theFieldVal := rowVal.Field(i)
-			theName := theField.Name
-			// see if we have a "sql" tag to use
-			theQueryResultName := theField.Tag.Get("sql")
-			if theQueryResultName == "" {
-				// else see if we have a "db" tag to use
-				theQueryResultName = theField.Tag.Get("db")
-			}
-			if theQueryResultName == "" && FieldNameTag != "" {
-				// else see if we have a custom tag to use
-				theQueryResultName = theField.Tag.Get(FieldNameTag)
-			}
-			if theQueryResultName == "" {
-				theQueryResultName = DefaultFieldNameStrConvFunc(theName)
-			}
-			if theQueryResultName == "-" {
+			if theNestedType := getNestedStructType(theField); theNestedType != nil {
 				// if we indicate that we have a nested struct, traverse it for names.
-				if theFieldVal.Kind() == reflect.Struct {
-					theEmbeddedFields := DetermineFieldsFromTableStruct(theFieldVal.Interface())
-					theResult = append(theResult, theEmbeddedFields...)
-				}
-
-			} else {
+				theEmbeddedFields := determineFieldsFromStructType(theNestedType)
+				theResult = append(theResult, theEmbeddedFields...)
+			} else if theQueryResultName := GetQueryFieldNameOfStructField(theField); theQueryResultName != "-" {
 				theResult = append(theResult, theQueryResultName)
 			}
 		}
@@ -75,11 +111,85 @@ func DetermineFieldsFromTableStruct( aTableStruct interface{} ) []string {
 	return theResult
 }
 
+// DefaultSortTag Struct field tag used to declare a field as part of the default
+// sort along with its direction, e.g. `defaultsort:"DESC"`.
+const DefaultSortTag = "defaultsort"
+
+// GetDefaultSortFromStruct Returns the default sort declared by the struct's
+// `defaultsort:"ASC"` or `defaultsort:"DESC"` field tags, by query field name, in
+// the order the fields are declared; use with ApplyOrderBySequence().
+// aTableStruct may be a struct or a pointer to one.
+func GetDefaultSortFromStruct( aTableStruct interface{} ) OrderBySequence {
+	return getDefaultSortFromStructType(indirectType(reflect.TypeOf(aTableStruct)), OrderBySequence{})
+}
+
+// getDefaultSortFromStructType Appends the default sort fields to aList.
+func getDefaultSortFromStructType( aStructType reflect.Type, aList OrderBySequence ) OrderBySequence {
+	if aStructType == nil || aStructType.Kind() != reflect.Struct {
+		return aList
+	}
+	for i:=0; i<aStructType.NumField(); i++ {
+		theField := aStructType.Field(i)
+		if IsStructFieldExported(theField) {
+			if theNestedType := getNestedStructType(theField); theNestedType != nil {
+				aList = getDefaultSortFromStructType(theNestedType, aList)
+			} else if theSortTag, ok := theField.Tag.Lookup(DefaultSortTag); ok {
+				theQueryResultName := GetQueryFieldNameOfStructField(theField)
+				if theQueryResultName == "-" {
+					continue
+				}
+				theDirection := ORDER_BY_ASCENDING
+				if strings.ToUpper(strings.TrimSpace(theSortTag)) == ORDER_BY_DESCENDING {
+					theDirection = ORDER_BY_DESCENDING
+				}
+				aList = append(aList, OrderBy{Field: theQueryResultName, Direction: theDirection})
+			}
+		}
+	}
+	return aList
+}
+
+// upperFirst Returns the string with its first letter in upper case,
+// e.g. "userName" becomes "UserName".
+func upperFirst( aStr string ) string {
+	r, size := utf8.DecodeRuneInString(aStr)
+	if r == utf8.RuneError {
+		return aStr
+	}
+	return string(unicode.ToUpper(r)) + aStr[size:]
+}
+
+// getStructFieldForQueryName Find the exported struct field that maps to the
+// query field name using the same naming rules as DetermineFieldsFromTableStruct;
+// the Go field name itself (with its first letter upper-cased) also matches.
+func getStructFieldForQueryName( aStructType reflect.Type, aFieldName string ) (reflect.StructField, bool) {
+	aStructType = indirectType(aStructType)
+	if aStructType == nil || aStructType.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i:=0; i<aStructType.NumField(); i++ {
+		theField := aStructType.Field(i)
+		if !IsStructFieldExported(theField) {
+			continue
+		}
+		theQueryResultName := GetQueryFieldNameOfStructField(theField)
+		if theNestedType := getNestedStructType(theField); theNestedType != nil {
+			// nested struct fields are part of our field list, check them too.
+			if theNestedField, found := getStructFieldForQueryName(theNestedType, aFieldName); found {
+				return theNestedField, true
+			}
+		} else if theQueryResultName != "-" &&
+			(theQueryResultName == aFieldName || theField.Name == upperFirst(aFieldName)) {
+			return theField, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
 // IsFieldSortable Returns TRUE if the fieldname specified is sortable.
 // Set public field tag to `sortable:"false"` if its not sortable.
 func IsFieldSortable( aTableStruct interface{}, aFieldName string ) bool {
-	theName := strings.ToTitle(aFieldName)
-	theField, found := reflect.TypeOf(aTableStruct).FieldByName(theName)
+	theField, found := getStructFieldForQueryName(reflect.TypeOf(aTableStruct), aFieldName)
 	if found {
 		theSortableTag := theField.Tag.Get("sortable")
 		return theSortableTag != "false"
@@ -104,14 +214,27 @@ func GetSanitizedOrderByList( aTableStruct interface{}, aList OrderByList ) Orde
 	return sList
 }
 
+// GetSanitizedOrderByListOrDefault Same as the sanitizer's GetSanitizedOrderByList()
+// except that if none of the supplied fields survive sanitization, the sanitizer's
+// GetDefaultSort() is returned instead so that the query retains a stable order.
+func GetSanitizedOrderByListOrDefault( aSanitizer ISqlSanitizer, aList OrderByList ) OrderByList {
+	sList := aSanitizer.GetSanitizedOrderByList(aList)
+	if len(sList) == 0 {
+		return aSanitizer.GetDefaultSort()
+	}
+	return sList
+}
+
 // GetSanitizedFieldList Prune the field list to remove any invalid fields.
+// Fields are matched against the column names of DetermineFieldsFromTableStruct,
+// e.g. "created_at" for a CreatedAt field tagged `db:"created_at"`. A field
+// requested by its Go name is replaced by its column name.
 func GetSanitizedFieldList( aTableStruct interface{}, aFieldList []string ) []string {
 	var sList []string
 	for _, v := range aFieldList {
-		theName := strings.ToTitle(v)
-		_, found := reflect.TypeOf(aTableStruct).FieldByName(theName)
+		theField, found := getStructFieldForQueryName(reflect.TypeOf(aTableStruct), v)
 		if found {
-			sList = append(sList, v)
+			sList = append(sList, GetQueryFieldNameOfStructField(theField))
 		}
 	}
 	return sList
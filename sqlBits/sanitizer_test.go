@@ -0,0 +1,128 @@
+package sqlBits
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testSanitizer An ISqlSanitizer for tests allowing the fields of testRecord.
+type testSanitizer struct {
+	fields   []string
+	sortable map[string]bool
+	defSort  OrderByList
+}
+
+func (s testSanitizer) GetDefinedFields() []string { return s.fields }
+func (s testSanitizer) IsFieldSortable( aFieldName string ) bool { return s.sortable[aFieldName] }
+func (s testSanitizer) GetDefaultSort() OrderByList { return s.defSort }
+func (s testSanitizer) GetSanitizedOrderByList( aList OrderByList ) OrderByList {
+	theList := OrderByList{}
+	for k, v := range aList {
+		if s.IsFieldSortable(k) {
+			theList[k] = v
+		}
+	}
+	return theList
+}
+func (s testSanitizer) GetSanitizedFieldList( aFieldList []string ) []string {
+	var theList []string
+	for _, theField := range aFieldList {
+		for _, theDefined := range s.fields {
+			if theField == theDefined {
+				theList = append(theList, theField)
+			}
+		}
+	}
+	return theList
+}
+
+
+func TestGetSanitizedOrderByListOrDefault( t *testing.T ) {
+	theSanitizer := testSanitizer{sortable: map[string]bool{"a": true}, defSort: OrderByList{"id": "ASC"}}
+	tests := []struct {
+		name string
+		list OrderByList
+		want OrderByList
+	}{
+		{"sortable", OrderByList{"a": "DESC", "b": "ASC"}, OrderByList{"a": "DESC"}},
+		{"none sortable", OrderByList{"b": "ASC"}, OrderByList{"id": "ASC"}},
+		{"empty", nil, OrderByList{"id": "ASC"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if got := GetSanitizedOrderByListOrDefault(theSanitizer, tt.list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type testAudit struct {
+	CreatedAt string `db:"created_at" defaultsort:"DESC"`
+}
+
+type testRecord struct {
+	ID       int    `db:"id" defaultsort:"ASC"`
+	Name     string `sortable:"false"`
+	Secret   string `db:"-"`
+	Audit    testAudit `db:"-"`
+	*TestStamps
+	internal string
+}
+
+// TestStamps Exported so that embedding it exports its fields.
+type TestStamps struct {
+	UpdatedBy string `db:"updated_by"`
+}
+
+func TestStructSanitizing( t *testing.T ) {
+	tests := []struct {
+		name     string
+		field    string
+		sortable bool
+		column   []string
+	}{
+		{"column name", "id", true, []string{"id"}},
+		{"go name", "ID", true, []string{"id"}},
+		{"lower case go name", "createdAt", true, []string{"created_at"}},
+		{"nested", "created_at", true, []string{"created_at"}},
+		{"embedded pointer", "updated_by", true, []string{"updated_by"}},
+		{"not sortable", "name", false, []string{"name"}},
+		{"skipped", "secret", false, nil},
+		{"unexported", "internal", false, nil},
+		{"unknown", "1; DROP TABLE t", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if got := IsFieldSortable(testRecord{}, tt.field); got != tt.sortable {
+				t.Errorf("IsFieldSortable() got %v", got)
+			}
+			if got := GetSanitizedFieldList(&testRecord{}, []string{tt.field}); !reflect.DeepEqual(got, tt.column) {
+				t.Errorf("GetSanitizedFieldList() got %v, want %v", got, tt.column)
+			}
+		})
+	}
+	theList := GetSanitizedOrderByList(testRecord{}, OrderByList{"id": "ASC", "name": "DESC", "x": "ASC"})
+	if !reflect.DeepEqual(theList, OrderByList{"id": "ASC"}) {
+		t.Errorf("GetSanitizedOrderByList() got %v", theList)
+	}
+}
+
+func TestDetermineFieldsFromTableStruct( t *testing.T ) {
+	theWant := []string{"id", "name", "created_at", "updated_by"}
+	for _, theStruct := range []interface{}{testRecord{}, &testRecord{}} {
+		if got := DetermineFieldsFromTableStruct(theStruct); !reflect.DeepEqual(got, theWant) {
+			t.Errorf("got %v, want %v", got, theWant)
+		}
+	}
+}
+
+func TestGetDefaultSortFromStruct( t *testing.T ) {
+	theWant := OrderBySequence{{"id", ORDER_BY_ASCENDING}, {"created_at", ORDER_BY_DESCENDING}}
+	for i := 0; i < 10; i += 1 {
+		// declaration order, never map order
+		if got := GetDefaultSortFromStruct(&testRecord{}); !reflect.DeepEqual(got, theWant) {
+			t.Fatalf("got %v, want %v", got, theWant)
+		}
+	}
+}
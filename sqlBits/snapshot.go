@@ -0,0 +1,59 @@
+package sqlBits
+
+import (
+	"sort"
+)
+
+// BuilderSnapshot The shape of a built query, its SQL and param keys but not
+// their values, in a form that can be serialized (e.g. as JSON) for caching.
+type BuilderSnapshot struct {
+	// The SQL statement as built, with ":name" params.
+	SQL string `json:"sql"`
+	// The param keys in the order they first appear in the SQL followed by
+	// any others, sorted.
+	ParamKeys []string `json:"param_keys"`
+	// The keys of the params that are value sets.
+	SetParamKeys []string `json:"set_param_keys,omitempty"`
+}
+
+// Export Returns a snapshot of our SQL and param keys; see ImportSnapshot().
+func (sqlbldr *Builder) Export() BuilderSnapshot {
+	theSnapshot := BuilderSnapshot{
+		SQL: sqlbldr.mySql,
+		ParamKeys: []string{},
+	}
+	theSeen := map[string]bool{}
+	for _, theToken := range getParamTokens(sqlbldr.mySql) {
+		if _, ok := sqlbldr.myParams[theToken.key]; ok && !theSeen[theToken.key] {
+			theSeen[theToken.key] = true
+			theSnapshot.ParamKeys = append(theSnapshot.ParamKeys, theToken.key)
+		}
+	}
+	var theOthers []string
+	for k := range sqlbldr.myParams {
+		if !theSeen[k] {
+			theOthers = append(theOthers, k)
+		}
+	}
+	sort.Strings(theOthers)
+	theSnapshot.ParamKeys = append(theSnapshot.ParamKeys, theOthers...)
+	for k := range sqlbldr.mySetParams {
+		theSnapshot.SetParamKeys = append(theSnapshot.SetParamKeys, k)
+	}
+	sort.Strings(theSnapshot.SetParamKeys)
+	return theSnapshot
+}
+
+// ImportSnapshot Returns a new Builder for the model with the SQL and param keys
+// of the snapshot; see Export(). Params start out NULL (and value sets nil) so
+// bind their values with SetParam(), SetParamSet(), etc. before use.
+func ImportSnapshot( aSnapshot BuilderSnapshot, aDbModeler DbModeler ) *Builder {
+	theBuilder := NewBuilder(aDbModeler).StartWith(aSnapshot.SQL)
+	for _, k := range aSnapshot.ParamKeys {
+		theBuilder.myParams[k] = nil
+	}
+	for _, k := range aSnapshot.SetParamKeys {
+		theBuilder.SetParamSet(k, nil)
+	}
+	return theBuilder
+}
@@ -0,0 +1,45 @@
+package sqlBits
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRoundTrip( t *testing.T ) {
+	b := newTestBuilder(PostgreSQL).StartWith("SELECT * FROM t").StartWhereClause().
+		SetParam("z", "1").MustAddParam("z").And().
+		SetParamSet("s", &[]string{"a", "b"}).MustAddParam("s").
+		SetParam("unused", "x")
+	theSnapshot := b.Export()
+	theWant := BuilderSnapshot{
+		SQL:          `SELECT * FROM t WHERE "z"=:z AND "s" IN (:s_1,:s_2)`,
+		ParamKeys:    []string{"z", "s_1", "s_2", "s", "unused"},
+		SetParamKeys: []string{"s"},
+	}
+	if !reflect.DeepEqual(theSnapshot, theWant) {
+		t.Fatalf("got %+v, want %+v", theSnapshot, theWant)
+	}
+	theJson, err := json.Marshal(theSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var theDecoded BuilderSnapshot
+	if err = json.Unmarshal(theJson, &theDecoded); err != nil {
+		t.Fatal(err)
+	}
+	theImport := ImportSnapshot(theDecoded, testModel{(&DriverInfo{}).SetDriverName(string(PostgreSQL))})
+	if got := theImport.GetSQLStatement(); got != theWant.SQL {
+		t.Errorf("imported SQL %q", got)
+	}
+	if !theImport.IsParamASet("s") || theImport.ParamCount() != 4 {
+		t.Errorf("imported params %v", theImport.SQLparams())
+	}
+	if err = theImport.Validate(); err != nil {
+		t.Errorf("imported builder is not valid: %v", err)
+	}
+	theImport.SetParam("z", "2").SetParam("s_1", "c").SetParam("s_2", "d")
+	if got := theImport.SQLargs(); !reflect.DeepEqual(got, []interface{}{"2", "c", "d"}) {
+		t.Errorf("imported builder args %v", got)
+	}
+}
@@ -0,0 +1,101 @@
+package sqlBits
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// sqlStater Errors, such as pgx's PgError, able to report their SQLSTATE.
+type sqlStater interface {
+	SQLState() string
+}
+
+// GetSQLState Returns the 5 character SQLSTATE of a driver error, or "" if none
+// can be found. Since we do not depend on any driver, the error (or any error it
+// wraps) is inspected for a SQLState() method, a SQLState [5]byte field as with
+// MySQL's *mysql.MySQLError, or a 5 character Code field as with pq's *pq.Error.
+// SQLite errors do not have a SQLSTATE.
+func GetSQLState( err error ) string {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if theStater, ok := err.(sqlStater); ok {
+			return theStater.SQLState()
+		}
+		theErrVal := reflect.ValueOf(err)
+		for theErrVal.Kind() == reflect.Ptr || theErrVal.Kind() == reflect.Interface {
+			theErrVal = theErrVal.Elem()
+		}
+		if theErrVal.Kind() != reflect.Struct {
+			continue
+		}
+		if theField := theErrVal.FieldByName("SQLState"); theField.IsValid() &&
+			theField.Kind() == reflect.Array && theField.Len() == 5 &&
+			theField.Type().Elem().Kind() == reflect.Uint8 {
+			theState := make([]byte, 5)
+			for i := 0; i < 5; i += 1 {
+				theState[i] = byte(theField.Index(i).Uint())
+			}
+			if theState[0] != 0 {
+				return string(theState)
+			}
+		}
+		if theField := theErrVal.FieldByName("Code"); theField.IsValid() &&
+			theField.Kind() == reflect.String && theField.Len() == 5 {
+			return theField.String()
+		}
+	}
+	return ""
+}
+
+// IsNoData Returns TRUE if the error means no rows were found, i.e. sql.ErrNoRows
+// or the SQLSTATE_NO_DATA state.
+func IsNoData( err error ) bool {
+	return errors.Is(err, sql.ErrNoRows) || GetSQLState(err) == SQLSTATE_NO_DATA
+}
+
+// IsTableMissing Returns TRUE if the error means a table referenced in the SQL
+// does not exist. SQLite errors lack a SQLSTATE so their message is checked.
+func IsTableMissing( err error ) bool {
+	if err == nil {
+		return false
+	}
+	switch GetSQLState(err) {
+	case SQLSTATE_TABLE_DOES_NOT_EXIST, SQLSTATE_UNDEFINED_TABLE:
+		return true
+	}//switch
+	return strings.Contains(err.Error(), "no such table")
+}
+
+// mysqlErrDeadlock MySQL error number of ER_LOCK_DEADLOCK.
+const mysqlErrDeadlock = 1213
+// mysqlErrLockWaitTimeout MySQL error number of ER_LOCK_WAIT_TIMEOUT.
+const mysqlErrLockWaitTimeout = 1205
+
+// IsRetriable Returns TRUE if the error means the transaction failed due to
+// contention with others and may succeed if retried, i.e. a deadlock, lock wait
+// timeout, or serialization failure.
+func IsRetriable( err error ) bool {
+	switch GetSQLState(err) {
+	case SQLSTATE_SERIALIZATION_FAILURE, SQLSTATE_DEADLOCK_DETECTED:
+		return true
+	}//switch
+	// MySQL reports a lock wait timeout with a generic state, check its number
+	for ; err != nil; err = errors.Unwrap(err) {
+		theErrVal := reflect.ValueOf(err)
+		for theErrVal.Kind() == reflect.Ptr || theErrVal.Kind() == reflect.Interface {
+			theErrVal = theErrVal.Elem()
+		}
+		if theErrVal.Kind() != reflect.Struct {
+			continue
+		}
+		if theField := theErrVal.FieldByName("Number"); theField.IsValid() &&
+			theField.Kind() >= reflect.Uint && theField.Kind() <= reflect.Uint64 {
+			switch theField.Uint() {
+			case mysqlErrDeadlock, mysqlErrLockWaitTimeout:
+				return true
+			}//switch
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package sqlBits
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testPgError Mimics pgx's PgError, reporting its state with a method.
+type testPgError struct{ state string }
+
+func (e *testPgError) Error() string { return "pg: " + e.state }
+func (e *testPgError) SQLState() string { return e.state }
+
+// testMySQLError Mimics *mysql.MySQLError.
+type testMySQLError struct {
+	Number   uint16
+	SQLState [5]byte
+	Message  string
+}
+
+func (e *testMySQLError) Error() string { return e.Message }
+
+// testPqError Mimics *pq.Error, whose Code is its SQLSTATE.
+type testPqError struct {
+	Code    string
+	Message string
+}
+
+func (e testPqError) Error() string { return e.Message }
+
+func TestSQLState( t *testing.T ) {
+	tests := []struct {
+		name         string
+		err          error
+		state        string
+		noData       bool
+		tableMissing bool
+	}{
+		{"nil", nil, "", false, false},
+		{"no rows", sql.ErrNoRows, "", true, false},
+		{"wrapped no rows", fmt.Errorf("find: %w", sql.ErrNoRows), "", true, false},
+		{"no data state", &testPgError{SQLSTATE_NO_DATA}, SQLSTATE_NO_DATA, true, false},
+		{"pgx undefined table", &testPgError{"42P01"}, "42P01", false, true},
+		{"pq short code", testPqError{Code: "42"}, "", false, false},
+		{"mysql missing table", &testMySQLError{1146, [5]byte{'4', '2', 'S', '0', '2'}, "no table"},
+			"42S02", false, true},
+		{"mysql without state", &testMySQLError{Number: 1045, Message: "denied"}, "", false, false},
+		{"sqlite missing table", errors.New("no such table: users"), "", false, true},
+		{"plain", errors.New("boom"), "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if got := GetSQLState(tt.err); got != tt.state {
+				t.Errorf("GetSQLState() got %q, want %q", got, tt.state)
+			}
+			if got := IsNoData(tt.err); got != tt.noData {
+				t.Errorf("IsNoData() got %v", got)
+			}
+			if got := IsTableMissing(tt.err); got != tt.tableMissing {
+				t.Errorf("IsTableMissing() got %v", got)
+			}
+		})
+	}
+}
+
+func TestIsRetriable( t *testing.T ) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"pgx serialization", fmt.Errorf("tx: %w", &testPgError{"40001"}), true},
+		{"pq deadlock", testPqError{Code: "40P01"}, true},
+		{"mysql deadlock", &testMySQLError{1213, [5]byte{'4', '0', '0', '0', '1'}, "deadlock"}, true},
+		{"mysql lock wait timeout", fmt.Errorf("tx: %w", &testMySQLError{1205, [5]byte{'H', 'Y', '0', '0', '0'}, "timeout"}), true},
+		{"pgx undefined table", &testPgError{"42P01"}, false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if got := IsRetriable(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
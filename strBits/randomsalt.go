@@ -1,49 +1,204 @@
 package strBits
 
 import (
-	"math/rand"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
 	"strings"
-	"time"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 // GenerateRandomStr Iterate over the chars in aDestStr, converting them to
-// random chars chosen from aRandSource.
+// random chars chosen from aRandSource. Uses crypto/rand with rejection sampling
+// so that every char of aRandSource is equally likely to be chosen.
 func GenerateRandomStr( aRandSource string, aDestStr string ) string {
-	theRandLen := len(aRandSource)
+	theResult, err := generateRandomStrFrom(rand.Reader, aRandSource, aDestStr)
+	if err != nil {
+		panic(err)
+	}
+	return theResult
+}
+
+// GenerateRandomStrN Random string of aLen chars chosen from aRandSource using
+// random bytes read from aReader; a nil aReader means crypto/rand. Handy for
+// tests, since the same bytes always produce the same string.
+func GenerateRandomStrN( aReader io.Reader, aRandSource string, aLen int ) (string, error) {
+	if aReader == nil {
+		aReader = rand.Reader
+	}
+	if aLen < 0 {
+		aLen = 0
+	}
+	return generateRandomStrFrom(aReader, aRandSource, strings.Repeat(".", aLen))
+}
+
+// generateRandomStrFrom Iterate over the chars in aDestStr, converting them to
+// random chars chosen from aRandSource using random bytes read from aReader.
+func generateRandomStrFrom( aReader io.Reader, aRandSource string, aDestStr string ) (string, error) {
 	theDestAsRunes := []rune(aDestStr)
 	theRandAsRunes := []rune(aRandSource)
-	for k := range theDestAsRunes {
-		idx := rand.Intn(theRandLen)
-		theDestAsRunes[k] = theRandAsRunes[idx]
+	theRandLen := len(theRandAsRunes)
+	if theRandLen == 0 {
+		return "", errors.New("strBits: random source string is empty")
+	}
+	if theRandLen > 256 {
+		// too many chars to pick with a single byte, let crypto/rand pick fairly
+		theMax := big.NewInt(int64(theRandLen))
+		for k := range theDestAsRunes {
+			idx, err := rand.Int(aReader, theMax)
+			if err != nil {
+				return "", err
+			}
+			theDestAsRunes[k] = theRandAsRunes[idx.Int64()]
+		}
+		return string(theDestAsRunes), nil
+	}
+	// bytes at or above theLimit would favor the lower indexes with a plain
+	// modulo, so they get rejected and another byte is used instead.
+	theLimit := 256 - (256 % theRandLen)
+	theBuffer := make([]byte, len(theDestAsRunes) + len(theDestAsRunes)/4 + 1)
+	thePos := len(theBuffer)
+	for k := 0; k < len(theDestAsRunes); {
+		if thePos >= len(theBuffer) {
+			if _, err := io.ReadFull(aReader, theBuffer); err != nil {
+				return "", err
+			}
+			thePos = 0
+		}
+		b := int(theBuffer[thePos])
+		thePos += 1
+		if b < theLimit {
+			theDestAsRunes[k] = theRandAsRunes[b % theRandLen]
+			k += 1
+		}
 	}
-	return string(theDestAsRunes)
+	return string(theDestAsRunes), nil
 }
 
+// Base64Charset The default salt alphabet, the crypt(3) chars starting with "/.".
 const Base64Charset = "/.ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+// StdBase64Charset The standard base64 alphabet (RFC 4648).
+const StdBase64Charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+// BcryptBase64Charset The alphabet, in order, used by bcrypt for its salts.
+const BcryptBase64Charset = "./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+// UrlSafeBase64Charset The URL and filename safe base64 alphabet (RFC 4648).
+const UrlSafeBase64Charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
 
 // UrlSafeRandomStr Random string with just ".", "0 thru 9", and "A-Z,a-z".
 //
 // Pass in 0 for "default length" which is 16.
 func UrlSafeRandomStr( aLen int ) string {
+	theResult, err := UrlSafeRandomStrWithReader(rand.Reader, aLen)
+	if err != nil {
+		panic(err)
+	}
+	return theResult
+}
+
+// UrlSafeRandomStrWithReader Same as UrlSafeRandomStr() except that random
+// bytes are read from aReader; a nil aReader means crypto/rand.
+func UrlSafeRandomStrWithReader( aReader io.Reader, aLen int ) (string, error) {
 	theRandSource := Base64Charset[1:]
 	// min length is 1, default to 16 if less than 1
 	if aLen < 1 {
 		aLen = 16
 	}
-	return GenerateRandomStr(theRandSource, strings.Repeat(".", aLen))
+	return GenerateRandomStrN(aReader, theRandSource, aLen)
 }
 
 // Base64RandomSalt Random string with the Base64Charset characters.
 //
 // Pass in 0 for "default length" which is 16.
 func Base64RandomSalt( aLen int ) string {
+	theResult, err := Base64RandomSaltWithReader(rand.Reader, aLen)
+	if err != nil {
+		panic(err)
+	}
+	return theResult
+}
+
+// Base64RandomSaltWithReader Same as Base64RandomSalt() except that random
+// bytes are read from aReader; a nil aReader means crypto/rand.
+func Base64RandomSaltWithReader( aReader io.Reader, aLen int ) (string, error) {
 	// min length is 1, default to 16 if less than 1
 	if aLen < 1 {
 		aLen = 16
 	}
-	return GenerateRandomStr(Base64Charset, strings.Repeat(".", aLen))
+	return GenerateRandomStrN(aReader, Base64Charset, aLen)
+}
+
+// MinSaltLen The fewest chars RandomSalt() and MustRandomSalt() will generate;
+// shorter salts are too weak to be of much use.
+var MinSaltLen = 8
+
+// RandomSalt Random string of aLen chars with the Base64Charset characters.
+// Unlike Base64RandomSalt(), there is no default length; asking for fewer than
+// MinSaltLen chars returns an error rather than a weak salt.
+func RandomSalt( aLen int ) (string, error) {
+	if aLen < MinSaltLen {
+		return "", fmt.Errorf("strBits: salt length %d is less than the minimum of %d", aLen, MinSaltLen)
+	}
+	return GenerateRandomStrN(rand.Reader, Base64Charset, aLen)
+}
+
+// MustRandomSalt Same as RandomSalt() except that it panics on error, such as
+// asking for fewer than MinSaltLen chars.
+func MustRandomSalt( aLen int ) string {
+	theResult, err := RandomSalt(aLen)
+	if err != nil {
+		panic(err)
+	}
+	return theResult
+}
+
+// Base64RandomSaltWithCharset Random string with the characters of aCharset,
+// e.g. StdBase64Charset, BcryptBase64Charset, or UrlSafeBase64Charset.
+// An empty aCharset means Base64Charset.
+//
+// Pass in 0 for "default length" which is 16.
+func Base64RandomSaltWithCharset( aLen int, aCharset string ) string {
+	if aCharset == "" {
+		aCharset = Base64Charset
+	}
+	// min length is 1, default to 16 if less than 1
+	if aLen < 1 {
+		aLen = 16
+	}
+	return GenerateRandomStr(aCharset, strings.Repeat(".", aLen))
+}
+
+// GenerateRandomBytes Returns aLen cryptographically secure random bytes; a
+// negative aLen is an error.
+func GenerateRandomBytes( aLen int ) ([]byte, error) {
+	if aLen < 0 {
+		return nil, fmt.Errorf("strBits: random byte length %d is negative", aLen)
+	}
+	theBytes := make([]byte, aLen)
+	if _, err := rand.Read(theBytes); err != nil {
+		return nil, err
+	}
+	return theBytes, nil
+}
+
+// RandomHex Hex encoded string of aLen cryptographically secure random bytes,
+// so the result is twice aLen in length.
+func RandomHex( aLen int ) (string, error) {
+	theBytes, err := GenerateRandomBytes(aLen)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(theBytes), nil
+}
+
+// RandomBase64URL Unpadded URL-safe base64 encoded string of aLen cryptographically
+// secure random bytes, suitable for tokens used in URLs.
+func RandomBase64URL( aLen int ) (string, error) {
+	theBytes, err := GenerateRandomBytes(aLen)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(theBytes), nil
 }
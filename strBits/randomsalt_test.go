@@ -0,0 +1,242 @@
+package strBits
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	mathrand "math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRandomBytesAndEncoders( t *testing.T ) {
+	tests := []struct {
+		name string
+		len  int
+	}{
+		{"zero", 0},
+		{"one", 1},
+		{"token", 32},
+		{"odd", 33},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			theBytes, err := GenerateRandomBytes(tt.len)
+			if err != nil || len(theBytes) != tt.len {
+				t.Errorf("GenerateRandomBytes got %d bytes, err %v; want %d", len(theBytes), err, tt.len)
+			}
+			theHex, err := RandomHex(tt.len)
+			if err != nil || len(theHex) != tt.len*2 {
+				t.Errorf("RandomHex got %q, err %v", theHex, err)
+			}
+			if b, err := hex.DecodeString(theHex); err != nil || len(b) != tt.len {
+				t.Errorf("RandomHex %q is not valid hex of %d bytes", theHex, tt.len)
+			}
+			theB64, err := RandomBase64URL(tt.len)
+			if err != nil {
+				t.Fatalf("RandomBase64URL error %v", err)
+			}
+			if b, err := base64.RawURLEncoding.DecodeString(theB64); err != nil || len(b) != tt.len {
+				t.Errorf("RandomBase64URL %q is not valid base64url of %d bytes", theB64, tt.len)
+			}
+		})
+	}
+}
+
+func TestRandomBytesAndEncoders_NegativeLength( t *testing.T ) {
+	if _, err := GenerateRandomBytes(-1); err == nil {
+		t.Error("GenerateRandomBytes expected an error")
+	}
+	if _, err := RandomHex(-1); err == nil {
+		t.Error("RandomHex expected an error")
+	}
+	if _, err := RandomBase64URL(-1); err == nil {
+		t.Error("RandomBase64URL expected an error")
+	}
+}
+
+func TestGenerateRandomStr_IsUniform( t *testing.T ) {
+	tests := []struct {
+		name    string
+		charset string
+	}{
+		{"63 chars", Base64Charset[1:]},
+		{"64 chars", Base64Charset},
+		{"10 chars", "0123456789"},
+	}
+	const theSampleLen = 200000
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			theSample, err := GenerateRandomStrN(mathrand.New(mathrand.NewSource(7)), tt.charset, theSampleLen)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			theCounts := map[rune]int{}
+			for _, c := range theSample {
+				theCounts[c] += 1
+			}
+			theExpected := float64(theSampleLen) / float64(len(tt.charset))
+			for _, c := range tt.charset {
+				if d := float64(theCounts[c]) - theExpected; d > theExpected*0.1 || d < -theExpected*0.1 {
+					t.Errorf("char %q chosen %d times, expected about %.0f", c, theCounts[c], theExpected)
+				}
+			}
+		})
+	}
+}
+
+// isFromCharset Returns TRUE if every char of aStr is one of aCharset.
+func isFromCharset( aStr string, aCharset string ) bool {
+	for _, c := range aStr {
+		if !strings.ContainsRune(aCharset, c) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRandomSalts_UseTheirCharset( t *testing.T ) {
+	tests := []struct {
+		name    string
+		gen     func() string
+		charset string
+		len     int
+	}{
+		{"UrlSafeRandomStr default", func() string { return UrlSafeRandomStr(0) }, Base64Charset[1:], 16},
+		{"UrlSafeRandomStr", func() string { return UrlSafeRandomStr(24) }, Base64Charset[1:], 24},
+		{"Base64RandomSalt default", func() string { return Base64RandomSalt(-1) }, Base64Charset, 16},
+		{"GenerateRandomStr", func() string { return GenerateRandomStr("xyz", "......") }, "xyz", 6},
+		{"default charset", func() string { return Base64RandomSaltWithCharset(12, "") }, Base64Charset, 12},
+		{"standard", func() string { return Base64RandomSaltWithCharset(40, StdBase64Charset) }, StdBase64Charset, 40},
+		{"bcrypt", func() string { return Base64RandomSaltWithCharset(22, BcryptBase64Charset) }, BcryptBase64Charset, 22},
+		{"url safe", func() string { return Base64RandomSaltWithCharset(0, UrlSafeBase64Charset) }, UrlSafeBase64Charset, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			got := tt.gen()
+			if len(got) != tt.len {
+				t.Errorf("got %d chars, want %d", len(got), tt.len)
+			}
+			if !isFromCharset(got, tt.charset) {
+				t.Errorf("%q has chars outside of the charset", got)
+			}
+		})
+	}
+}
+
+func TestGenerateRandomStrN_SeededReaderIsDeterministic( t *testing.T ) {
+	tests := []struct {
+		name    string
+		reader  func() io.Reader
+		charset string
+		len     int
+		want    string
+	}{
+		{"counting bytes", func() io.Reader { return bytes.NewReader([]byte("\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d")) },
+			"0123456789", 10, "0123456789"},
+		{"base64", func() io.Reader { return mathrand.New(mathrand.NewSource(42)) },
+			Base64Charset, 32, "RK9Uvi9ZV5dJywmdZSCwQHHXy8Q4HbbQ"},
+		{"url safe", func() io.Reader { return mathrand.New(mathrand.NewSource(42)) },
+			UrlSafeBase64Charset, 16, "TM_Wxk_bX7fL0yof"},
+		{"digits", func() io.Reader { return mathrand.New(mathrand.NewSource(42)) },
+			"0123456789", 10, "3070701717"},
+		{"more than 256 chars", func() io.Reader { return mathrand.New(mathrand.NewSource(42)) },
+			strings.Repeat("ab", 200), 8, "aabbabaa"},
+		{"zero length", func() io.Reader { return mathrand.New(mathrand.NewSource(42)) },
+			Base64Charset, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			for _, theSeed := range []int64{1, 99} {
+				// reseeding the global math/rand source must not affect the result
+				mathrand.Seed(theSeed)
+				got, err := GenerateRandomStrN(tt.reader(), tt.charset, tt.len)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("after Seed(%d) got %q, want %q", theSeed, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateRandomStrN_FixedBytes( t *testing.T ) {
+	tests := []struct {
+		name    string
+		bytes   []byte
+		charset string
+		len     int
+		want    string
+	}{
+		{"direct index", []byte{0, 1, 2, 3}, "abcd", 4, "abcd"},
+		{"modulo", []byte{4, 5, 6, 7}, "abcd", 4, "abcd"},
+		// 3 chars means bytes 255 and up are rejected; 255 is skipped
+		{"rejects biased bytes", []byte{255, 0, 1, 2}, "abc", 3, "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			// pad the reader so the whole buffer can be filled
+			theReader := bytes.NewReader(append(tt.bytes, make([]byte, 16)...))
+			got, err := GenerateRandomStrN(theReader, tt.charset, tt.len)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateRandomStrN_Errors( t *testing.T ) {
+	tests := []struct {
+		name    string
+		reader  *bytes.Reader
+		charset string
+	}{
+		{"empty charset", bytes.NewReader(make([]byte, 64)), ""},
+		{"short reader", bytes.NewReader([]byte{1}), Base64Charset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			if _, err := GenerateRandomStrN(tt.reader, tt.charset, 8); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRandomSalt_MinLength( t *testing.T ) {
+	tests := []struct {
+		name    string
+		len     int
+		wantErr bool
+	}{
+		{"zero", 0, true},
+		{"one below the minimum", MinSaltLen - 1, true},
+		{"the minimum", MinSaltLen, false},
+		{"above the minimum", MinSaltLen + 8, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func( t *testing.T ) {
+			got, err := RandomSalt(tt.len)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(got) != tt.len {
+				t.Errorf("got %d chars, want %d", len(got), tt.len)
+			}
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != tt.wantErr {
+						t.Errorf("MustRandomSalt panic %v, want panic %v", r, tt.wantErr)
+					}
+				}()
+				MustRandomSalt(tt.len)
+			}()
+		})
+	}
+}